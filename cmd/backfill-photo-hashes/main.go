@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/media"
+	"dating-app/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// Hashes and indexes the photos uploaded before the duplicate detection was introduced,
+// then flags their near duplicates on other accounts for review
+// Photos already indexed are skipped so the command can be run again after a failure
+func main() {
+
+	// Load Environment variables
+	core.LoadConfig()
+
+	// Initiate Db Connection
+	fmt.Println("Establishing Database connection")
+	core.InitDb()
+
+	// Initiate Media Storage
+	blobStore, err := media.NewBlobStore(core.AppConfig.MEDIA_STORE, core.AppConfig.MEDIA_LOCAL_PATH)
+	if err != nil {
+		log.Fatal("Failed to initiate media storage:", err)
+	}
+
+	// The photos without hash bands were never indexed
+	var photos []models.Photo
+	err = core.GetDb().
+		Where("id NOT IN (?)", core.GetDb().Model(&models.PhotoHashBand{}).Select("photo_id")).
+		Order("id ASC").
+		Find(&photos).Error
+	if err != nil {
+		log.Fatal("Error fetching photos:", err)
+	}
+
+	// Every photo is indexed before looking for duplicates so the photos being
+	// backfilled are compared with each other as well
+	indexed := []models.Photo{}
+	for _, photo := range photos {
+		hashes, err := hashStoredPhoto(blobStore, photo)
+		if err != nil {
+			fmt.Printf("photo=%d skipped: %v\n", photo.ID, err)
+			continue
+		}
+		photo.PHash = hashes.PHash
+		photo.DHash = hashes.DHash
+
+		err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&photo).Select("PHash", "DHash").Updates(photo).Error; err != nil {
+				return err
+			}
+			return media.IndexPhotoHashes(tx, photo)
+		})
+		if err != nil {
+			log.Fatalf("Error indexing photo %d: %v", photo.ID, err)
+		}
+		indexed = append(indexed, photo)
+	}
+
+	flagged := 0
+	for _, photo := range indexed {
+		flags, err := media.FindDuplicatePhotos(core.GetDb(), photo, core.AppConfig.PHOTO_DUPLICATE_MAX_DISTANCE)
+		if err != nil {
+			log.Fatalf("Error looking for duplicates of photo %d: %v", photo.ID, err)
+		}
+		for _, flag := range flags {
+			if err := media.RecordDuplicatePhotoFlag(core.GetDb(), flag); err != nil {
+				log.Fatalf("Error recording duplicate photo flag: %v", err)
+			}
+			fmt.Printf("photo=%d user=%d matches photo=%d user=%d\n", flag.PhotoID, flag.UserID, flag.MatchedPhotoID, flag.MatchedUserID)
+			flagged++
+		}
+	}
+
+	fmt.Printf("\nIndexed %d of %d photos, %d near duplicates found\n", len(indexed), len(photos), flagged)
+}
+
+// hashStoredPhoto hashes the largest variant of the photo, the original file isn't kept
+func hashStoredPhoto(blobStore media.BlobStore, photo models.Photo) (media.ImageHashes, error) {
+	key, exists := photo.Variants[media.Variants[len(media.Variants)-1].Name]
+	if !exists {
+		return media.ImageHashes{}, fmt.Errorf("no stored variant")
+	}
+
+	file, err := blobStore.Open(key)
+	if err != nil {
+		return media.ImageHashes{}, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return media.ImageHashes{}, err
+	}
+	return media.HashEncoded(data)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// Promotes an existing user to the admin role so the first admin
+// can manage the roles of the other users through the admin endpoints
+func main() {
+
+	email := flag.String("email", "", "Email of the user to promote to admin")
+	force := flag.Bool("force", false, "Promote the user even if an admin already exists")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("Missing required -email flag")
+	}
+
+	// Load Environment variables
+	core.LoadConfig()
+
+	// Initiate Db Connection
+	fmt.Println("Establishing Database connection")
+	core.InitDb()
+
+	// Only the first admin is meant to be created from the command line
+	var adminCount int64
+	if err := core.GetDb().Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&adminCount).Error; err != nil {
+		log.Fatal("Error counting admins:", err)
+	}
+	if adminCount > 0 && !*force {
+		log.Fatal("An admin already exists, use the admin endpoints or the -force flag")
+	}
+
+	var user models.User
+	err := core.GetDb().Where("email = ?", *email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Fatalf("User not found: %s", *email)
+	}
+	if err != nil {
+		log.Fatal("Error retrieving user:", err)
+	}
+
+	if err := core.GetDb().Model(&user).Update("role", models.RoleAdmin).Error; err != nil {
+		log.Fatal("Error promoting user:", err)
+	}
+
+	fmt.Printf("User %d (%s) is now an admin\n", user.ID, user.Email)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/ledger"
+)
+
+// Checks the credits ledger for inconsistencies, the cached balances of the accounts
+// are compared with their entries and every transaction must sum up to zero
+// Exits with a non-zero status when an inconsistency is found so it can run as a scheduled job
+func main() {
+
+	// Load Environment variables
+	core.LoadConfig()
+
+	// Initiate Db Connection
+	fmt.Println("Establishing Database connection")
+	core.InitDb()
+
+	report, err := ledger.Reconcile(core.GetDb())
+	if err != nil {
+		log.Fatal("Error reconciling the ledger:", err)
+	}
+
+	for _, mismatch := range report.Mismatches {
+		fmt.Printf("account=%s balance=%d entries=%d\n", mismatch.Account, mismatch.Balance, mismatch.EntriesSum)
+	}
+	for _, transactionID := range report.UnbalancedTransactions {
+		fmt.Printf("transaction=%d is unbalanced\n", transactionID)
+	}
+
+	if !report.OK() {
+		fmt.Printf("\n%d accounts and %d transactions are inconsistent\n", len(report.Mismatches), len(report.UnbalancedTransactions))
+		os.Exit(1)
+	}
+	fmt.Println("The ledger is consistent")
+}
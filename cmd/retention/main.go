@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+)
+
+// Rows deleted per statement so the tables are never locked for long
+const deleteBatchSize = 10000
+
+// Deletes the rows kept for a limited time once their retention period is over
+// Meant to run as a scheduled job, daily for instance
+func main() {
+
+	// Load Environment variables
+	core.LoadConfig()
+
+	// Initiate Db Connection
+	fmt.Println("Establishing Database connection")
+	core.InitDb()
+
+	now := time.Now()
+
+	// Streaming clients reconnecting after the events they missed were deleted are asked to resync
+	eventsCutoff := now.AddDate(0, 0, -core.AppConfig.USER_EVENT_RETENTION_DAYS)
+	deleted, err := deleteBefore(&models.UserEvent{}, eventsCutoff)
+	if err != nil {
+		log.Fatal("Error deleting user events:", err)
+	}
+	fmt.Printf("Deleted %d user events created before %s\n", deleted, eventsCutoff.Format(time.RFC3339))
+
+	// The spam detector only looks at the last day, the fetches are kept longer for the spam-replay command
+	fetchesCutoff := now.AddDate(0, 0, -core.AppConfig.DISCOVER_FETCH_RETENTION_DAYS)
+	deleted, err = deleteBefore(&models.DiscoverFetch{}, fetchesCutoff)
+	if err != nil {
+		log.Fatal("Error deleting discover fetches:", err)
+	}
+	fmt.Printf("Deleted %d discover fetches created before %s\n", deleted, fetchesCutoff.Format(time.RFC3339))
+}
+
+// deleteBefore deletes the rows of the model created before the cutoff in batches
+func deleteBefore(model interface{}, cutoff time.Time) (int64, error) {
+	var deleted int64
+	for {
+		result := core.GetDb().Where("created_at < ?", cutoff).Limit(deleteBatchSize).Delete(model)
+		if result.Error != nil {
+			return deleted, result.Error
+		}
+		deleted += result.RowsAffected
+		if result.RowsAffected < deleteBatchSize {
+			return deleted, nil
+		}
+	}
+}
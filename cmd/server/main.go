@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 
 	"dating-app/pkg/core"
+	"dating-app/pkg/entitlements"
+	"dating-app/pkg/media"
+	"dating-app/pkg/notify"
 	"dating-app/pkg/routes"
 )
 
@@ -13,20 +17,60 @@ func main() {
 	// Load Environment variables
 	core.LoadConfig()
 
+	// The media links are unauthenticated, signing them with the public development key would let anyone forge them
+	if core.AppConfig.ENVIRONMENT != "development" && core.AppConfig.MEDIA_SIGNING_KEY == core.DevelopmentMediaSigningKey {
+		log.Fatal("MEDIA_SIGNING_KEY must be set outside development")
+	}
+
 	// Initiate Db Connection
 	fmt.Println("Establishing Database connection")
 	core.InitDb()
 
+	// Initiate Push Notifications
+	notifier, err := notify.NewNotifier(core.AppConfig.NOTIFIER, core.AppConfig.NOTIFIER_FILE_PATH, core.AppConfig.NOTIFIER_HTTP_URL)
+	if err != nil {
+		log.Fatal("Failed to initiate push notifications:", err)
+	}
+	notify.SetNotifier(notifier)
+
+	// Initiate Media Storage
+	blobStore, err := media.NewBlobStore(core.AppConfig.MEDIA_STORE, core.AppConfig.MEDIA_LOCAL_PATH)
+	if err != nil {
+		log.Fatal("Failed to initiate media storage:", err)
+	}
+	media.SetBlobStore(blobStore)
+
+	// Purchases are rejected until the app stores are integrated,
+	// the mock receipt verifier is only trusted in development
+	if core.AppConfig.ENVIRONMENT == "development" {
+		entitlements.SetVerifier(entitlements.NewMockReceiptVerifier())
+	}
+
 	// Initiate Routers
 	fmt.Println("Registering Routes")
 	mux := http.NewServeMux()
 	routes.RegisterUserRoutes(mux)
 	routes.RegisterDiscoverRoutes(mux)
 	routes.RegisterSwipeRoutes(mux)
+	routes.RegisterLikesRoutes(mux)
+	routes.RegisterMessageRoutes(mux)
+	routes.RegisterRealtimeRoutes(mux)
+	routes.RegisterDeviceRoutes(mux)
+	routes.RegisterBlockRoutes(mux)
+	routes.RegisterModerationRoutes(mux)
+	routes.RegisterAdminRoutes(mux)
+	routes.RegisterSubscriptionRoutes(mux)
+	routes.RegisterBoostRoutes(mux)
+	routes.RegisterCreditsRoutes(mux)
+	routes.RegisterPreferenceRoutes(mux)
+	routes.RegisterGenderRoutes(mux)
+	routes.RegisterPhotoRoutes(mux)
+	routes.RegisterProfileRoutes(mux)
+	routes.RegisterQuestionRoutes(mux)
 
 	// Run Server
 	fmt.Println("Server is running on port 8888")
-	err := http.ListenAndServe(":8888", mux)
+	err = http.ListenAndServe(":8888", mux)
 	if err != nil {
 		fmt.Println("Error starting server:", err)
 	}
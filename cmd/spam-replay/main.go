@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"dating-app/pkg/antispam"
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+)
+
+// Replays the spam detection rules over the historical swipes to tune their thresholds
+// The thresholds default to the configured ones and can be overridden with flags
+// Nothing is written to the database unless the -record flag is set
+func main() {
+
+	// Load Environment variables
+	core.LoadConfig()
+	thresholds := antispam.ConfiguredThresholds()
+
+	rules := flag.String("rules", strings.Join(thresholds.EnabledRules, ","), "Comma separated rules to replay")
+	since := flag.Duration("since", 30*24*time.Hour, "Replay the swipes made during this period")
+	userID := flag.Uint64("user", 0, "Only replay the swipes of this user")
+	record := flag.Bool("record", false, "Record the flags in the database")
+	flag.DurationVar(&thresholds.Lookback, "lookback", thresholds.Lookback, "Activity period evaluated at every swipe")
+	flag.DurationVar(&thresholds.VelocityWindow, "velocity-window", thresholds.VelocityWindow, "Window of the velocity rule")
+	flag.IntVar(&thresholds.VelocityMaxSwipes, "velocity-max-swipes", thresholds.VelocityMaxSwipes, "Maximum swipes within the velocity window")
+	flag.IntVar(&thresholds.LikeRatioMinSwipes, "like-ratio-min-swipes", thresholds.LikeRatioMinSwipes, "Minimum swipes before evaluating the like ratio")
+	flag.Float64Var(&thresholds.LikeRatioMax, "like-ratio-max", thresholds.LikeRatioMax, "Maximum like ratio")
+	flag.IntVar(&thresholds.DiscoverGapMinSwipes, "discover-gap-min-swipes", thresholds.DiscoverGapMinSwipes, "Minimum swipes before evaluating the discover gap")
+	flag.DurationVar(&thresholds.DiscoverGapMin, "discover-gap-min", thresholds.DiscoverGapMin, "Minimum median gap between a discover fetch and a swipe")
+	flag.Parse()
+
+	thresholds.EnabledRules = strings.Split(*rules, ",")
+	detector := antispam.NewDetector(thresholds)
+
+	// Initiate Db Connection
+	fmt.Println("Establishing Database connection")
+	core.InitDb()
+
+	start := time.Now().Add(-*since)
+
+	var userIDs []uint64
+	if *userID != 0 {
+		userIDs = []uint64{*userID}
+	} else {
+		err := core.GetDb().Model(&models.Swipe{}).Where("created_at > ?", start).Distinct().Pluck("swiper_id", &userIDs).Error
+		if err != nil {
+			log.Fatal("Error fetching swipers:", err)
+		}
+	}
+
+	// The activity before the replayed period is loaded as well so the first
+	// swipes of the period are evaluated with a full lookback window
+	flaggedUsers := map[string]int{}
+	for _, id := range userIDs {
+		activity, err := antispam.LoadActivity(core.GetDb(), id, start.Add(-thresholds.Lookback), 0)
+		if err != nil {
+			log.Fatalf("Error loading the activity of user %d: %v", id, err)
+		}
+
+		for _, verdict := range detector.Replay(activity) {
+			if verdict.At.Before(start) {
+				continue
+			}
+
+			flaggedUsers[verdict.Rule]++
+			fmt.Printf("user=%d rule=%s at=%s detail=%q\n", verdict.UserID, verdict.Rule, verdict.At.Format(time.RFC3339), verdict.Detail)
+
+			if *record {
+				if err := antispam.RecordFlag(core.GetDb(), verdict); err != nil {
+					log.Fatalf("Error recording the flag of user %d: %v", id, err)
+				}
+			}
+		}
+	}
+
+	fmt.Printf("\nReplayed %d users since %s\n", len(userIDs), start.Format(time.RFC3339))
+	ruleNames := make([]string, 0, len(detector.Rules))
+	for _, rule := range detector.Rules {
+		ruleNames = append(ruleNames, rule.Name())
+	}
+	sort.Strings(ruleNames)
+	for _, name := range ruleNames {
+		fmt.Printf("%-14s %d users flagged\n", name, flaggedUsers[name])
+	}
+}
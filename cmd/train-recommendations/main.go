@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/recommend"
+
+	"gorm.io/gorm"
+)
+
+// Trains the collaborative filtering model of discover on the swipe history and stores it
+// The previous models are deleted once the new one is stored, discover always uses the latest
+func main() {
+
+	// Load Environment variables
+	core.LoadConfig()
+	config := recommend.DefaultConfig()
+
+	since := flag.Duration("since", 180*24*time.Hour, "Train on the swipes made during this period, zero for the whole history")
+	dryRun := flag.Bool("dry-run", false, "Train without storing the model")
+	flag.IntVar(&config.Factors, "factors", config.Factors, "Size of the user embeddings")
+	flag.IntVar(&config.Epochs, "epochs", config.Epochs, "Passes over the swipes")
+	flag.Float64Var(&config.LearningRate, "learning-rate", config.LearningRate, "Learning rate of the gradient descent")
+	flag.Float64Var(&config.Regularization, "regularization", config.Regularization, "L2 regularization of the embeddings")
+	flag.Int64Var(&config.Seed, "seed", config.Seed, "Seed of the initial embeddings")
+	flag.Parse()
+
+	// Initiate Db Connection
+	fmt.Println("Establishing Database connection")
+	core.InitDb()
+
+	interactions := []recommend.Interaction{}
+	query := core.GetDb().Model(&models.Swipe{}).Select("id, swiper_id, target_id, swipe_type")
+	if *since > 0 {
+		query = query.Where("created_at > ?", time.Now().Add(-*since))
+	}
+	var swipes []models.Swipe
+	err := query.FindInBatches(&swipes, 5000, func(tx *gorm.DB, batch int) error {
+		for _, swipe := range swipes {
+			interactions = append(interactions, recommend.Interaction{
+				SwiperID: swipe.SwiperID,
+				TargetID: swipe.TargetID,
+				Liked:    swipe.SwipeType == "YES",
+			})
+		}
+		return nil
+	}).Error
+	if err != nil {
+		log.Fatal("Error fetching swipes:", err)
+	}
+	if len(interactions) == 0 {
+		log.Fatal("No swipes to train on")
+	}
+
+	start := time.Now()
+	model := recommend.Train(interactions, config)
+	fmt.Printf("Trained on %d swipes of %d users in %s, loss %.4f\n", len(interactions), len(model.Embeddings), time.Since(start).Round(time.Millisecond), model.Loss)
+
+	if *dryRun {
+		return
+	}
+
+	stored := models.RecommendationModel{
+		Factors:      model.Factors,
+		GlobalBias:   model.GlobalBias,
+		Interactions: int64(len(interactions)),
+		Users:        int64(len(model.Embeddings)),
+		Loss:         model.Loss,
+		TrainedAt:    time.Now(),
+	}
+	err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&stored).Error; err != nil {
+			return err
+		}
+
+		embeddings := make([]models.UserEmbedding, 0, len(model.Embeddings))
+		for userID, embedding := range model.Embeddings {
+			embeddings = append(embeddings, models.UserEmbedding{
+				ModelID:    stored.ID,
+				UserID:     userID,
+				Taste:      embedding.Taste,
+				Appeal:     embedding.Appeal,
+				TasteBias:  embedding.TasteBias,
+				AppealBias: embedding.AppealBias,
+			})
+		}
+		if err := tx.CreateInBatches(&embeddings, 1000).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("model_id < ?", stored.ID).Delete(&models.UserEmbedding{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id < ?", stored.ID).Delete(&models.RecommendationModel{}).Error
+	})
+	if err != nil {
+		log.Fatal("Error storing the model:", err)
+	}
+
+	fmt.Printf("Stored model %d\n", stored.ID)
+}
@@ -0,0 +1,38 @@
+package antispam
+
+import (
+	"time"
+)
+
+// SwipeRecord is a single swipe of the watched user
+type SwipeRecord struct {
+	Like bool
+	At   time.Time
+}
+
+// Activity is the recent behaviour of a user the rules are evaluated against
+// Swipes and discover fetches are sorted from the oldest to the newest
+type Activity struct {
+	UserID          uint64
+	Swipes          []SwipeRecord
+	DiscoverFetches []time.Time
+}
+
+// window returns the activity that happened in the lookback period ending at the given time
+func (a Activity) window(end time.Time, lookback time.Duration) Activity {
+	start := end.Add(-lookback)
+	windowed := Activity{UserID: a.UserID}
+
+	for _, swipe := range a.Swipes {
+		if swipe.At.After(start) && !swipe.At.After(end) {
+			windowed.Swipes = append(windowed.Swipes, swipe)
+		}
+	}
+	for _, fetch := range a.DiscoverFetches {
+		if fetch.After(start) && !fetch.After(end) {
+			windowed.DiscoverFetches = append(windowed.DiscoverFetches, fetch)
+		}
+	}
+
+	return windowed
+}
@@ -0,0 +1,22 @@
+package antispam
+
+import (
+	"strings"
+	"time"
+
+	"dating-app/pkg/core"
+)
+
+// ConfiguredThresholds returns the thresholds of the spam detector set in the configuration
+func ConfiguredThresholds() Thresholds {
+	return Thresholds{
+		EnabledRules:         strings.Split(core.AppConfig.SPAM_RULES, ","),
+		Lookback:             time.Duration(core.AppConfig.SPAM_LOOKBACK_MINUTES) * time.Minute,
+		VelocityWindow:       time.Duration(core.AppConfig.SPAM_VELOCITY_WINDOW_SECONDS) * time.Second,
+		VelocityMaxSwipes:    core.AppConfig.SPAM_VELOCITY_MAX_SWIPES,
+		LikeRatioMinSwipes:   core.AppConfig.SPAM_LIKE_RATIO_MIN_SWIPES,
+		LikeRatioMax:         core.AppConfig.SPAM_LIKE_RATIO_MAX,
+		DiscoverGapMinSwipes: core.AppConfig.SPAM_DISCOVER_GAP_MIN_SWIPES,
+		DiscoverGapMin:       time.Duration(core.AppConfig.SPAM_DISCOVER_GAP_MIN_MS) * time.Millisecond,
+	}
+}
@@ -0,0 +1,109 @@
+package antispam
+
+import (
+	"strings"
+	"time"
+)
+
+// Thresholds configures the rules of the detector
+type Thresholds struct {
+	EnabledRules         []string
+	Lookback             time.Duration
+	VelocityWindow       time.Duration
+	VelocityMaxSwipes    int
+	LikeRatioMinSwipes   int
+	LikeRatioMax         float64
+	DiscoverGapMinSwipes int
+	DiscoverGapMin       time.Duration
+}
+
+// Verdict is a rule triggered by the activity of a user
+type Verdict struct {
+	UserID uint64
+	Rule   string
+	Detail string
+	At     time.Time
+}
+
+// Detector evaluates a set of rules against the activity of a user
+// over the lookback period ending at their latest swipe
+type Detector struct {
+	Rules    []Rule
+	Lookback time.Duration
+}
+
+func NewDetector(thresholds Thresholds) *Detector {
+	available := map[string]Rule{
+		RuleVelocity:    VelocityRule{Window: thresholds.VelocityWindow, MaxSwipes: thresholds.VelocityMaxSwipes},
+		RuleLikeRatio:   LikeRatioRule{MinSwipes: thresholds.LikeRatioMinSwipes, MaxRatio: thresholds.LikeRatioMax},
+		RuleDiscoverGap: DiscoverGapRule{MinSwipes: thresholds.DiscoverGapMinSwipes, MinMedianGap: thresholds.DiscoverGapMin},
+	}
+
+	detector := &Detector{Lookback: thresholds.Lookback}
+	for _, name := range thresholds.EnabledRules {
+		if rule, ok := available[strings.TrimSpace(name)]; ok {
+			detector.Rules = append(detector.Rules, rule)
+		}
+	}
+	return detector
+}
+
+// Evaluate returns the rules triggered by the activity of the user
+func (d *Detector) Evaluate(activity Activity) []Verdict {
+	if len(activity.Swipes) == 0 {
+		return nil
+	}
+
+	last := activity.Swipes[len(activity.Swipes)-1].At
+	return d.evaluateWindow(activity.window(last, d.Lookback), last)
+}
+
+// Replay walks through the whole history of a user as if the detector had been running
+// at every swipe, and returns the first time each rule was triggered
+func (d *Detector) Replay(activity Activity) []Verdict {
+	triggered := map[string]bool{}
+	var verdicts []Verdict
+
+	// The lookback window slides along the history, both slices are sorted
+	// so the bounds of the window only ever move forward
+	swipeStart, fetchStart, fetchEnd := 0, 0, 0
+	for i, swipe := range activity.Swipes {
+		start := swipe.At.Add(-d.Lookback)
+		for swipeStart < i && !activity.Swipes[swipeStart].At.After(start) {
+			swipeStart++
+		}
+		for fetchStart < len(activity.DiscoverFetches) && !activity.DiscoverFetches[fetchStart].After(start) {
+			fetchStart++
+		}
+		for fetchEnd < len(activity.DiscoverFetches) && !activity.DiscoverFetches[fetchEnd].After(swipe.At) {
+			fetchEnd++
+		}
+
+		windowed := Activity{
+			UserID:          activity.UserID,
+			Swipes:          activity.Swipes[swipeStart : i+1],
+			DiscoverFetches: activity.DiscoverFetches[min(fetchStart, fetchEnd):fetchEnd],
+		}
+		for _, verdict := range d.evaluateWindow(windowed, swipe.At) {
+			if !triggered[verdict.Rule] {
+				triggered[verdict.Rule] = true
+				verdicts = append(verdicts, verdict)
+			}
+		}
+		if len(triggered) == len(d.Rules) {
+			break
+		}
+	}
+
+	return verdicts
+}
+
+func (d *Detector) evaluateWindow(windowed Activity, at time.Time) []Verdict {
+	var verdicts []Verdict
+	for _, rule := range d.Rules {
+		if triggered, detail := rule.Evaluate(windowed); triggered {
+			verdicts = append(verdicts, Verdict{UserID: windowed.UserID, Rule: rule.Name(), Detail: detail, At: at})
+		}
+	}
+	return verdicts
+}
@@ -0,0 +1,122 @@
+package antispam
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Rule names, used to enable the rules from the configuration and to record the flags
+const (
+	RuleVelocity    = "velocity"
+	RuleLikeRatio   = "like_ratio"
+	RuleDiscoverGap = "discover_gap"
+)
+
+// Rule looks for a bot-like pattern in the activity of a user
+// It returns whether the activity triggers the rule and a human readable detail
+type Rule interface {
+	Name() string
+	Evaluate(activity Activity) (bool, string)
+}
+
+// VelocityRule triggers when the user swipes more than MaxSwipes times within Window
+// Every Window long period of the activity is checked, not only the one ending at the latest swipe,
+// so a burst is still caught when the activity is evaluated after the user paused
+type VelocityRule struct {
+	Window    time.Duration
+	MaxSwipes int
+}
+
+func (r VelocityRule) Name() string {
+	return RuleVelocity
+}
+
+func (r VelocityRule) Evaluate(activity Activity) (bool, string) {
+	if len(activity.Swipes) == 0 {
+		return false, ""
+	}
+
+	// The window slides along the swipes, which are sorted, keeping the busiest one
+	count, start := 0, 0
+	for end, swipe := range activity.Swipes {
+		for swipe.At.Sub(activity.Swipes[start].At) > r.Window {
+			start++
+		}
+		count = max(count, end-start+1)
+	}
+
+	if count > r.MaxSwipes {
+		return true, fmt.Sprintf("%d swipes in %s (max %d)", count, r.Window, r.MaxSwipes)
+	}
+	return false, ""
+}
+
+// LikeRatioRule triggers when the user likes almost everyone
+// Only users with at least MinSwipes swipes are evaluated
+type LikeRatioRule struct {
+	MinSwipes int
+	MaxRatio  float64
+}
+
+func (r LikeRatioRule) Name() string {
+	return RuleLikeRatio
+}
+
+func (r LikeRatioRule) Evaluate(activity Activity) (bool, string) {
+	if len(activity.Swipes) < r.MinSwipes || len(activity.Swipes) == 0 {
+		return false, ""
+	}
+
+	likes := 0
+	for _, swipe := range activity.Swipes {
+		if swipe.Like {
+			likes++
+		}
+	}
+
+	ratio := float64(likes) / float64(len(activity.Swipes))
+	if ratio > r.MaxRatio {
+		return true, fmt.Sprintf("like ratio %.2f over %d swipes (max %.2f)", ratio, len(activity.Swipes), r.MaxRatio)
+	}
+	return false, ""
+}
+
+// DiscoverGapRule triggers when the user swipes faster than a human could look at a profile
+// It measures the time between each swipe and the discover fetch preceding it
+// and compares the median gap of at least MinSwipes swipes with MinMedianGap
+type DiscoverGapRule struct {
+	MinSwipes    int
+	MinMedianGap time.Duration
+}
+
+func (r DiscoverGapRule) Name() string {
+	return RuleDiscoverGap
+}
+
+func (r DiscoverGapRule) Evaluate(activity Activity) (bool, string) {
+	gaps := make([]time.Duration, 0, len(activity.Swipes))
+
+	// Both slices are sorted so the latest fetch before each swipe can be tracked with a single pass
+	fetchIndex := -1
+	for _, swipe := range activity.Swipes {
+		for fetchIndex+1 < len(activity.DiscoverFetches) && !activity.DiscoverFetches[fetchIndex+1].After(swipe.At) {
+			fetchIndex++
+		}
+		if fetchIndex >= 0 {
+			gaps = append(gaps, swipe.At.Sub(activity.DiscoverFetches[fetchIndex]))
+		}
+	}
+
+	if len(gaps) < r.MinSwipes || len(gaps) == 0 {
+		return false, ""
+	}
+
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	median := gaps[len(gaps)/2]
+
+	if median < r.MinMedianGap {
+		return true, fmt.Sprintf("median gap of %s between discover and swipe over %d swipes (min %s)", median, len(gaps), r.MinMedianGap)
+	}
+	return false, ""
+}
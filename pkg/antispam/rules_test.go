@@ -0,0 +1,194 @@
+package antispam
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+
+// swipesEvery returns count swipes spaced by the interval, liking the first likes of them
+func swipesEvery(count int, interval time.Duration, likes int) []SwipeRecord {
+	swipes := make([]SwipeRecord, count)
+	for i := range swipes {
+		swipes[i] = SwipeRecord{Like: i < likes, At: start.Add(time.Duration(i) * interval)}
+	}
+	return swipes
+}
+
+// burstThenPause returns 40 swipes in 40 seconds followed by 10 swipes 30 seconds apart
+func burstThenPause() []SwipeRecord {
+	swipes := swipesEvery(40, time.Second, 0)
+	for i := 1; i <= 10; i++ {
+		swipes = append(swipes, SwipeRecord{At: swipes[39].At.Add(time.Duration(i) * 30 * time.Second)})
+	}
+	return swipes
+}
+
+func TestVelocityRule(t *testing.T) {
+	rule := VelocityRule{Window: time.Minute, MaxSwipes: 30}
+
+	tests := []struct {
+		name   string
+		swipes []SwipeRecord
+		want   bool
+	}{
+		{"no swipes", nil, false},
+		{"at the limit", swipesEvery(30, time.Second, 0), false},
+		{"over the limit", swipesEvery(31, time.Second, 0), true},
+		{"spread over more than the window", swipesEvery(40, 3*time.Second, 0), false},
+		{"window edge is inclusive", swipesEvery(31, 2*time.Second, 0), true},
+		{"burst followed by a pause", burstThenPause(), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, detail := rule.Evaluate(Activity{Swipes: test.swipes}); got != test.want {
+				t.Errorf("Evaluate() = %v (%s), want %v", got, detail, test.want)
+			}
+		})
+	}
+}
+
+func TestLikeRatioRule(t *testing.T) {
+	rule := LikeRatioRule{MinSwipes: 50, MaxRatio: 0.95}
+
+	tests := []struct {
+		name   string
+		swipes []SwipeRecord
+		want   bool
+	}{
+		{"too few swipes", swipesEvery(49, time.Minute, 49), false},
+		{"at the max ratio", swipesEvery(100, time.Minute, 95), false},
+		{"over the max ratio", swipesEvery(100, time.Minute, 96), true},
+		{"likes everyone", swipesEvery(50, time.Minute, 50), true},
+		{"picky", swipesEvery(200, time.Minute, 20), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, detail := rule.Evaluate(Activity{Swipes: test.swipes}); got != test.want {
+				t.Errorf("Evaluate() = %v (%s), want %v", got, detail, test.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverGapRule(t *testing.T) {
+	rule := DiscoverGapRule{MinSwipes: 3, MinMedianGap: 800 * time.Millisecond}
+
+	// withGaps returns a discover fetch followed by a swipe after each gap
+	withGaps := func(gaps ...time.Duration) Activity {
+		activity := Activity{}
+		for i, gap := range gaps {
+			fetch := start.Add(time.Duration(i) * time.Minute)
+			activity.DiscoverFetches = append(activity.DiscoverFetches, fetch)
+			activity.Swipes = append(activity.Swipes, SwipeRecord{At: fetch.Add(gap)})
+		}
+		return activity
+	}
+
+	tests := []struct {
+		name     string
+		activity Activity
+		want     bool
+	}{
+		{"too few swipes", withGaps(time.Millisecond, time.Millisecond), false},
+		{"human gaps", withGaps(2*time.Second, 5*time.Second, 3*time.Second), false},
+		{"bot gaps", withGaps(100*time.Millisecond, 200*time.Millisecond, 150*time.Millisecond), true},
+		{"median decides", withGaps(100*time.Millisecond, 5*time.Second, 900*time.Millisecond), false},
+		{"at the min gap", withGaps(800*time.Millisecond, 800*time.Millisecond, 800*time.Millisecond), false},
+		{"swipes without fetch are ignored", Activity{Swipes: swipesEvery(10, time.Millisecond, 0)}, false},
+		{
+			"latest fetch before the swipe is used",
+			Activity{
+				DiscoverFetches: []time.Time{start, start.Add(10 * time.Second)},
+				Swipes: []SwipeRecord{
+					{At: start.Add(10*time.Second + 100*time.Millisecond)},
+					{At: start.Add(10*time.Second + 200*time.Millisecond)},
+					{At: start.Add(10*time.Second + 300*time.Millisecond)},
+				},
+			},
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, detail := rule.Evaluate(test.activity); got != test.want {
+				t.Errorf("Evaluate() = %v (%s), want %v", got, detail, test.want)
+			}
+		})
+	}
+}
+
+func TestDetector(t *testing.T) {
+	thresholds := Thresholds{
+		EnabledRules:       []string{RuleVelocity, " like_ratio", "unknown"},
+		Lookback:           time.Hour,
+		VelocityWindow:     time.Minute,
+		VelocityMaxSwipes:  5,
+		LikeRatioMinSwipes: 10,
+		LikeRatioMax:       0.9,
+	}
+	detector := NewDetector(thresholds)
+	if len(detector.Rules) != 2 {
+		t.Fatalf("NewDetector() enabled %d rules, want 2", len(detector.Rules))
+	}
+
+	// A burst of likes two hours ago, then a few slow passes
+	activity := Activity{UserID: 7, Swipes: swipesEvery(10, time.Second, 10)}
+	for i := 0; i < 3; i++ {
+		activity.Swipes = append(activity.Swipes, SwipeRecord{At: start.Add(2*time.Hour + time.Duration(i)*time.Minute)})
+	}
+
+	if verdicts := detector.Evaluate(activity); len(verdicts) != 0 {
+		t.Errorf("Evaluate() = %v, want the burst to be out of the lookback period", verdicts)
+	}
+
+	verdicts := detector.Replay(activity)
+	if len(verdicts) != 2 {
+		t.Fatalf("Replay() = %v, want both rules", verdicts)
+	}
+	if verdicts[0].Rule != RuleVelocity || !verdicts[0].At.Equal(start.Add(5*time.Second)) {
+		t.Errorf("Replay() first verdict = %+v, want velocity at the 6th swipe", verdicts[0])
+	}
+	if verdicts[1].Rule != RuleLikeRatio || !verdicts[1].At.Equal(start.Add(9*time.Second)) {
+		t.Errorf("Replay() second verdict = %+v, want like_ratio at the 10th swipe", verdicts[1])
+	}
+	if verdicts[0].UserID != 7 {
+		t.Errorf("Replay() verdict user = %d, want 7", verdicts[0].UserID)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	throttle := NewThrottle()
+	interval := time.Minute
+
+	steps := []struct {
+		userID uint64
+		at     time.Duration
+		want   bool
+		// Delay of the postponed evaluation, zero when there is none to plan
+		delay time.Duration
+	}{
+		{1, 0, true, 0},
+		{1, 30 * time.Second, false, 30 * time.Second},
+		{2, 30 * time.Second, true, 0},
+		{1, 59 * time.Second, false, 0},
+		{1, time.Minute, false, time.Minute},
+		{2, 90 * time.Second, true, 0},
+		{2, 100 * time.Second, false, 50 * time.Second},
+		{1, 2 * time.Minute, false, time.Minute},
+		{1, 150 * time.Second, false, 0},
+		{2, 4 * time.Minute, true, 0},
+		{1, 270 * time.Second, true, 0},
+	}
+
+	for _, step := range steps {
+		got, delay := throttle.Allow(step.userID, start.Add(step.at), interval)
+		if got != step.want || delay != step.delay {
+			t.Errorf("Allow(%d, +%s) = %v, %s, want %v, %s", step.userID, step.at, got, delay, step.want, step.delay)
+		}
+	}
+}
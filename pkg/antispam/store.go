@@ -0,0 +1,58 @@
+package antispam
+
+import (
+	"time"
+
+	"dating-app/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// LoadActivity loads the swipes and discover fetches of the user since the given time
+// When limit is positive only the most recent swipes are loaded
+func LoadActivity(db *gorm.DB, userID uint64, since time.Time, limit int) (Activity, error) {
+	activity := Activity{UserID: userID}
+
+	query := db.Where("swiper_id = ? AND created_at > ?", userID, since).Order("created_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var swipes []models.Swipe
+	if err := query.Find(&swipes).Error; err != nil {
+		return activity, err
+	}
+
+	// Swipes were loaded newest first to keep the most recent ones
+	for i := len(swipes) - 1; i >= 0; i-- {
+		activity.Swipes = append(activity.Swipes, SwipeRecord{Like: swipes[i].SwipeType == "YES", At: swipes[i].CreatedAt})
+	}
+
+	var fetches []models.DiscoverFetch
+	if err := db.Where("user_id = ? AND created_at > ?", userID, since).Order("created_at ASC").Find(&fetches).Error; err != nil {
+		return activity, err
+	}
+	for _, fetch := range fetches {
+		activity.DiscoverFetches = append(activity.DiscoverFetches, fetch.CreatedAt)
+	}
+
+	return activity, nil
+}
+
+// RecordFlag flags the user unless the rule already has an open flag for them
+func RecordFlag(db *gorm.DB, verdict Verdict) error {
+	var count int64
+	err := db.Model(&models.SpamFlag{}).
+		Where("user_id = ? AND rule = ? AND status = ?", verdict.UserID, verdict.Rule, models.SpamFlagStatusOpen).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+
+	return db.Create(&models.SpamFlag{
+		UserID: verdict.UserID,
+		Rule:   verdict.Rule,
+		Detail: verdict.Detail,
+		Status: models.SpamFlagStatusOpen,
+	}).Error
+}
@@ -0,0 +1,51 @@
+package antispam
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle limits how often the activity of a user is evaluated, evaluating at every swipe
+// would load the same activity over and over for users swiping quickly
+// A throttled evaluation is postponed to the end of the interval rather than dropped, so the
+// activity following the last evaluation is always evaluated, even when the user stops swiping
+type Throttle struct {
+	mu        sync.Mutex
+	last      map[uint64]time.Time
+	lastSweep time.Time
+}
+
+func NewThrottle() *Throttle {
+	return &Throttle{last: map[uint64]time.Time{}}
+}
+
+// Allow reports whether the user wasn't evaluated during the interval and records the evaluation
+// When the user was, the first throttled call gets the delay after which the postponed evaluation
+// must run, the following calls get zero as the evaluation is already planned
+func (t *Throttle) Allow(userID uint64, now time.Time, interval time.Duration) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Forget the users whose interval is over so the map only holds the recently active users
+	if now.Sub(t.lastSweep) >= interval {
+		for id, at := range t.last {
+			if now.Sub(at) >= interval {
+				delete(t.last, id)
+			}
+		}
+		t.lastSweep = now
+	}
+
+	at, exists := t.last[userID]
+	if !exists || now.Sub(at) >= interval {
+		t.last[userID] = now
+		return true, 0
+	}
+	// A planned evaluation is recorded ahead of time
+	if at.After(now) {
+		return false, 0
+	}
+	next := at.Add(interval)
+	t.last[userID] = next
+	return false, next.Sub(now)
+}
@@ -2,8 +2,13 @@ package core
 
 import (
 	"os"
+	"strconv"
 )
 
+// DevelopmentMediaSigningKey signs the media links when MEDIA_SIGNING_KEY is not set,
+// it's public so the server refuses to start with it outside development
+const DevelopmentMediaSigningKey = "development-media-signing-key"
+
 type Config struct {
 	ENVIRONMENT    string
 	MYSQL_USER     string
@@ -11,6 +16,79 @@ type Config struct {
 	MYSQL_DATABASE string
 	MYSQL_HOST     string
 	MYSQL_PORT     string
+
+	// When enabled the likes inbox only returns the number of pending likes
+	// without revealing who sent them
+	LIKES_INBOX_COUNT_ONLY bool
+
+	// Push notifications delivery: log, file or http
+	NOTIFIER           string
+	NOTIFIER_FILE_PATH string
+	NOTIFIER_HTTP_URL  string
+
+	// Number of days the realtime events are kept for the streaming clients to resume from
+	USER_EVENT_RETENTION_DAYS int
+
+	// Spam detection rules and thresholds, see the antispam package
+	SPAM_RULES                   string
+	SPAM_LOOKBACK_MINUTES        int
+	SPAM_VELOCITY_WINDOW_SECONDS int
+	SPAM_VELOCITY_MAX_SWIPES     int
+	SPAM_LIKE_RATIO_MIN_SWIPES   int
+	SPAM_LIKE_RATIO_MAX          float64
+	SPAM_DISCOVER_GAP_MIN_SWIPES int
+	SPAM_DISCOVER_GAP_MIN_MS     int
+
+	// Minimum time between two evaluations of the activity of a user by the spam detector
+	// and number of days the discover requests are kept for it
+	SPAM_CHECK_INTERVAL_SECONDS   int
+	DISCOVER_FETCH_RETENTION_DAYS int
+
+	// Rate limit policies written as "<limit>/<window>", like "60/1m"
+	RATE_LIMIT_SIGNUP   string
+	RATE_LIMIT_LOGIN    string
+	RATE_LIMIT_DISCOVER string
+	RATE_LIMIT_SWIPE    string
+
+	// Number of likes a user can send per day, zero means unlimited
+	DAILY_LIKE_LIMIT int
+
+	// Largest discover distance users without the extended distance entitlement can choose
+	DISCOVER_MAX_DISTANCE_KM float64
+
+	// Credits paid for every like over the daily limit, zero disables paying for likes
+	CREDITS_PER_EXTRA_LIKE int
+
+	// Weighting of the interests in the compatibility of two users (idf or uniform)
+	// and how much the compatibility raises the discover rank
+	INTEREST_WEIGHTING        string
+	COMPATIBILITY_RANK_WEIGHT float64
+
+	// How much the predicted chance of a mutual like raises the discover rank,
+	// see the train-recommendations job
+	RECOMMENDATION_RANK_WEIGHT float64
+
+	// Profile boosts: how long they last, how many a user can start per week
+	// and how much they raise the rank of the user for viewers within the radius
+	BOOST_DURATION_MINUTES int
+	BOOSTS_PER_WEEK        int
+	BOOST_MULTIPLIER       float64
+	BOOST_RADIUS_KM        float64
+
+	// Profile photos storage: local, with the signed links to the files expiring after the TTL
+	MEDIA_STORE            string
+	MEDIA_LOCAL_PATH       string
+	MEDIA_BASE_URL         string
+	MEDIA_SIGNING_KEY      string
+	MEDIA_URL_TTL_MINUTES  int
+	PHOTO_MAX_UPLOAD_BYTES int64
+	PHOTO_MAX_PER_USER     int
+
+	// Largest Hamming distance between the perceptual hashes of two photos flagged as duplicates
+	PHOTO_DUPLICATE_MAX_DISTANCE int
+
+	// Use the X-Forwarded-For header as the client IP when running behind a proxy
+	TRUST_PROXY_HEADERS bool
 }
 
 var AppConfig Config
@@ -22,6 +100,36 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvBool(key string, fallback bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		parsed, err := strconv.ParseBool(value)
+		if err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		parsed, err := strconv.Atoi(value)
+		if err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
 func LoadConfig() {
 
 	AppConfig = Config{
@@ -31,5 +139,68 @@ func LoadConfig() {
 		MYSQL_DATABASE: getEnv("MYSQL_DATABASE", "dating_dating_db"),
 		MYSQL_HOST:     getEnv("MYSQL_HOST", "db"),
 		MYSQL_PORT:     getEnv("MYSQL_PORT", "3306"),
+
+		LIKES_INBOX_COUNT_ONLY: getEnvBool("LIKES_INBOX_COUNT_ONLY", false),
+
+		NOTIFIER:           getEnv("NOTIFIER", "log"),
+		NOTIFIER_FILE_PATH: getEnv("NOTIFIER_FILE_PATH", "notifications.log"),
+		NOTIFIER_HTTP_URL:  getEnv("NOTIFIER_HTTP_URL", "http://localhost:8889/push"),
+
+		USER_EVENT_RETENTION_DAYS: getEnvInt("USER_EVENT_RETENTION_DAYS", 7),
+
+		SPAM_RULES:                   getEnv("SPAM_RULES", "velocity,like_ratio,discover_gap"),
+		SPAM_LOOKBACK_MINUTES:        getEnvInt("SPAM_LOOKBACK_MINUTES", 24*60),
+		SPAM_VELOCITY_WINDOW_SECONDS: getEnvInt("SPAM_VELOCITY_WINDOW_SECONDS", 60),
+		SPAM_VELOCITY_MAX_SWIPES:     getEnvInt("SPAM_VELOCITY_MAX_SWIPES", 30),
+		SPAM_LIKE_RATIO_MIN_SWIPES:   getEnvInt("SPAM_LIKE_RATIO_MIN_SWIPES", 50),
+		SPAM_LIKE_RATIO_MAX:          getEnvFloat("SPAM_LIKE_RATIO_MAX", 0.95),
+		SPAM_DISCOVER_GAP_MIN_SWIPES: getEnvInt("SPAM_DISCOVER_GAP_MIN_SWIPES", 20),
+		SPAM_DISCOVER_GAP_MIN_MS:     getEnvInt("SPAM_DISCOVER_GAP_MIN_MS", 800),
+
+		SPAM_CHECK_INTERVAL_SECONDS:   getEnvInt("SPAM_CHECK_INTERVAL_SECONDS", 60),
+		DISCOVER_FETCH_RETENTION_DAYS: getEnvInt("DISCOVER_FETCH_RETENTION_DAYS", 30),
+
+		RATE_LIMIT_SIGNUP:   getEnv("RATE_LIMIT_SIGNUP", "5/1h"),
+		RATE_LIMIT_LOGIN:    getEnv("RATE_LIMIT_LOGIN", "10/1m"),
+		RATE_LIMIT_DISCOVER: getEnv("RATE_LIMIT_DISCOVER", "30/1m"),
+		RATE_LIMIT_SWIPE:    getEnv("RATE_LIMIT_SWIPE", "60/1m"),
+
+		DAILY_LIKE_LIMIT: getEnvInt("DAILY_LIKE_LIMIT", 100),
+
+		DISCOVER_MAX_DISTANCE_KM: getEnvFloat("DISCOVER_MAX_DISTANCE_KM", 100),
+
+		CREDITS_PER_EXTRA_LIKE: getEnvInt("CREDITS_PER_EXTRA_LIKE", 1),
+
+		INTEREST_WEIGHTING:        getEnv("INTEREST_WEIGHTING", "idf"),
+		COMPATIBILITY_RANK_WEIGHT: getEnvFloat("COMPATIBILITY_RANK_WEIGHT", 0.5),
+
+		RECOMMENDATION_RANK_WEIGHT: getEnvFloat("RECOMMENDATION_RANK_WEIGHT", 1),
+
+		BOOST_DURATION_MINUTES: getEnvInt("BOOST_DURATION_MINUTES", 30),
+		BOOSTS_PER_WEEK:        getEnvInt("BOOSTS_PER_WEEK", 1),
+		BOOST_MULTIPLIER:       getEnvFloat("BOOST_MULTIPLIER", 2),
+		BOOST_RADIUS_KM:        getEnvFloat("BOOST_RADIUS_KM", 50),
+
+		MEDIA_STORE:            getEnv("MEDIA_STORE", "local"),
+		MEDIA_LOCAL_PATH:       getEnv("MEDIA_LOCAL_PATH", "media"),
+		MEDIA_BASE_URL:         getEnv("MEDIA_BASE_URL", "http://localhost:8888"),
+		MEDIA_SIGNING_KEY:      getEnv("MEDIA_SIGNING_KEY", DevelopmentMediaSigningKey),
+		MEDIA_URL_TTL_MINUTES:  getEnvInt("MEDIA_URL_TTL_MINUTES", 60),
+		PHOTO_MAX_UPLOAD_BYTES: int64(getEnvInt("PHOTO_MAX_UPLOAD_BYTES", 10<<20)),
+		PHOTO_MAX_PER_USER:     getEnvInt("PHOTO_MAX_PER_USER", 6),
+
+		PHOTO_DUPLICATE_MAX_DISTANCE: getEnvInt("PHOTO_DUPLICATE_MAX_DISTANCE", 6),
+
+		TRUST_PROXY_HEADERS: getEnvBool("TRUST_PROXY_HEADERS", false),
+	}
+}
+
+// RateLimitPolicies returns the configured rate limit policy of every limited route
+func RateLimitPolicies() map[string]string {
+	return map[string]string{
+		"signup":   AppConfig.RATE_LIMIT_SIGNUP,
+		"login":    AppConfig.RATE_LIMIT_LOGIN,
+		"discover": AppConfig.RATE_LIMIT_DISCOVER,
+		"swipe":    AppConfig.RATE_LIMIT_SWIPE,
 	}
 }
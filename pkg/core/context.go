@@ -4,3 +4,6 @@ package core
 type ContextKey string
 
 const UserContextKey ContextKey = "user"
+
+// ImpersonationContextKey holds the models.Impersonation of the request when an admin acts as the user
+const ImpersonationContextKey ContextKey = "impersonation"
@@ -52,6 +52,46 @@ func InitDb() {
 	db.AutoMigrate(&models.Token{})
 	db.AutoMigrate(&models.Swipe{})
 	db.AutoMigrate(&models.Match{})
+	db.AutoMigrate(&models.Message{})
+	db.AutoMigrate(&models.ConversationRead{})
+	db.AutoMigrate(&models.UserEvent{})
+	db.AutoMigrate(&models.Device{})
+	db.AutoMigrate(&models.NotificationPreference{})
+	db.AutoMigrate(&models.Block{})
+	db.AutoMigrate(&models.Report{})
+	db.AutoMigrate(&models.ModerationCase{})
+	db.AutoMigrate(&models.ModerationAction{})
+	db.AutoMigrate(&models.Impersonation{})
+	db.AutoMigrate(&models.DiscoverFetch{})
+	db.AutoMigrate(&models.SpamFlag{})
+	db.AutoMigrate(&models.DailyLikeCount{})
+	db.AutoMigrate(&models.Subscription{})
+	db.AutoMigrate(&models.Boost{})
+	db.AutoMigrate(&models.LedgerAccount{})
+	db.AutoMigrate(&models.LedgerTransaction{})
+	db.AutoMigrate(&models.LedgerEntry{})
+	db.AutoMigrate(&models.DiscoveryPreference{})
+	db.AutoMigrate(&models.GenderIdentity{})
+	db.AutoMigrate(&models.Photo{})
+	db.AutoMigrate(&models.PhotoHashBand{})
+	db.AutoMigrate(&models.DuplicatePhotoFlag{})
+	db.AutoMigrate(&models.Prompt{})
+	db.AutoMigrate(&models.PromptAnswer{})
+	db.AutoMigrate(&models.Interest{})
+	db.AutoMigrate(&models.UserInterest{})
+	db.AutoMigrate(&models.Question{})
+	db.AutoMigrate(&models.QuestionAnswer{})
+	db.AutoMigrate(&models.QuestionnaireMatch{})
+	db.AutoMigrate(&models.RecommendationModel{})
+	db.AutoMigrate(&models.UserEmbedding{})
+
+	// Data migrations
+	if err := migrateGenderIdentities(db); err != nil {
+		log.Fatal("Failed to migrate the gender identities:", err)
+	}
+	if err := seedProfileCatalog(db); err != nil {
+		log.Fatal("Failed to seed the prompts and interests:", err)
+	}
 }
 
 func GetDb() *gorm.DB {
@@ -2,10 +2,18 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"dating-app/pkg/models"
+	"dating-app/pkg/ratelimit"
 	"dating-app/pkg/utils"
 
 	"gorm.io/gorm"
@@ -27,50 +35,185 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusUnauthorized, "Invalid Authorization header format"))
 			return
 		}
-		token := parts[1]
 
-		// Validate the token and bring the user object and
-		// inject it into the request context so it can be used inside the protected handler
-		isValid, user, err := validateToken(token)
-		if err != nil {
-			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error validating token"))
+		authenticate(w, r, parts[1], next)
+	}
+}
+
+// StreamAuthMiddleware authenticates long lived streaming connections
+// Browsers can't set headers on WebSocket and EventSource connections
+// so the token can also be sent in the "token" query parameter
+func StreamAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	authMiddleware := AuthMiddleware(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Header.Get("Authorization") != "" {
+			authMiddleware(w, r)
 			return
 		}
 
-		if !isValid {
-			// Invalid token, return unauthorized
-			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusUnauthorized, "Invalid token"))
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusUnauthorized, "Missing Authorization header"))
 			return
 		}
 
-		// Token is valid, inject user info into context
-		ctx := context.WithValue(r.Context(), UserContextKey, user)
+		authenticate(w, r, token, next)
+	}
+}
+
+func authenticate(w http.ResponseWriter, r *http.Request, token string, next http.HandlerFunc) {
+
+	// Validate the token and bring the user object and
+	// inject it into the request context so it can be used inside the protected handler
+	isValid, user, impersonation, err := validateToken(token)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error validating token"))
+		return
+	}
+
+	if !isValid {
+		// Invalid token, return unauthorized
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusUnauthorized, "Invalid token"))
+		return
+	}
+
+	// Suspended and banned users are locked out until a moderator reinstates them
+	if user.IsRestricted(time.Now()) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusForbidden, fmt.Sprintf("Account %s", user.Status)))
+		return
+	}
 
-		// Call the next handler with user context
-		next.ServeHTTP(w, r.WithContext(ctx))
+	// Token is valid, inject user info into context
+	ctx := context.WithValue(r.Context(), UserContextKey, user)
+
+	// Impersonation sessions are read-only, otherwise what the admin does couldn't be told apart
+	// from what the user does in any record
+	if impersonation != nil {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusForbidden, "Impersonation sessions are read-only"))
+			return
+		}
+		ctx = context.WithValue(ctx, ImpersonationContextKey, *impersonation)
 	}
+
+	// Call the next handler with user context
+	next.ServeHTTP(w, r.WithContext(ctx))
 }
 
-func validateToken(tokenValue string) (bool, models.User, error) {
+// RequireRole only lets through the users having one of the given roles
+// It must be composed with AuthMiddleware which injects the user in the context
+//
+//	core.AuthMiddleware(core.RequireRole(models.RoleAdmin)(handler))
+func RequireRole(roles ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+
+			user, ok := r.Context().Value(UserContextKey).(models.User)
+			if !ok || !slices.Contains(roles, user.Role) {
+				utils.WriteErrorResponse(w, utils.NewAppError(http.StatusForbidden, "Insufficient permissions"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+	}
+}
+
+// RateLimit limits the requests of every client according to the named policy
+// Requests are limited per user when the middleware is composed after AuthMiddleware
+// and per client IP otherwise
+//
+//	core.AuthMiddleware(core.RateLimit("swipe")(handler))
+func RateLimit(policyName string) func(http.HandlerFunc) http.HandlerFunc {
+	policy, err := ratelimit.ParsePolicy(RateLimitPolicies()[policyName])
+	if err != nil {
+		log.Fatalf("Invalid rate limit policy %q: %v", policyName, err)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+
+			key := "ip:" + clientIP(r)
+			if user, ok := r.Context().Value(UserContextKey).(models.User); ok {
+				key = "user:" + strconv.FormatUint(user.ID, 10)
+			}
+
+			result, err := ratelimit.GetStore().Take(policyName+":"+key, policy, time.Now())
+			if err != nil {
+				// Failing open, an unavailable store shouldn't take the API down
+				fmt.Printf("Error checking rate limit: %v\n", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Window)))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				utils.WriteErrorResponse(w, utils.NewAppError(http.StatusTooManyRequests, "Too many requests"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+	}
+}
+
+// clientIP returns the IP of the client, behind a trusted proxy the first
+// address of the X-Forwarded-For header is the client
+func clientIP(r *http.Request) string {
+	if AppConfig.TRUST_PROXY_HEADERS {
+		if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+			return strings.TrimSpace(strings.Split(forwardedFor, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
+
+// validateToken returns the user of the token, along with the impersonation
+// when the token was handed to an admin impersonating the user
+func validateToken(tokenValue string) (bool, models.User, *models.Impersonation, error) {
 
 	var user models.User
 	var token models.Token
+	var impersonation *models.Impersonation
 
 	tokenResult := GetDb().Where("Value = ?", tokenValue).First(&token)
 	if tokenResult.Error == gorm.ErrRecordNotFound {
-		return false, user, nil
-	}
-	if tokenResult.Error != nil {
-		return false, user, tokenResult.Error
+		// Fall back on the short lived tokens handed to admins impersonating a user
+		impersonation = &models.Impersonation{}
+		impersonationResult := GetDb().Where("token = ? AND expires_at > ?", tokenValue, time.Now()).First(impersonation)
+		if impersonationResult.Error == gorm.ErrRecordNotFound {
+			return false, user, nil, nil
+		}
+		if impersonationResult.Error != nil {
+			return false, user, nil, impersonationResult.Error
+		}
+		token.UserID = impersonation.UserID
+	} else if tokenResult.Error != nil {
+		return false, user, nil, tokenResult.Error
 	}
 
 	userResult := GetDb().Where("ID = ?", token.UserID).Omit("password", "Token").First(&user)
 	if userResult.Error == gorm.ErrRecordNotFound {
-		return false, user, nil
+		return false, user, nil, nil
 	}
 	if userResult.Error != nil {
-		return false, user, userResult.Error
+		return false, user, nil, userResult.Error
 	}
 
-	return true, user, nil
+	return true, user, impersonation, nil
 }
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"dating-app/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrateGenderIdentities seeds the gender identities and maps the free-text genders
+// users had before them, it only touches the rows that weren't migrated yet
+func migrateGenderIdentities(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		defaults := slices.Clone(models.DefaultGenderIdentities)
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&defaults).Error; err != nil {
+			return err
+		}
+
+		var identities []models.GenderIdentity
+		if err := tx.Find(&identities).Error; err != nil {
+			return err
+		}
+		bySlug := map[string]models.GenderIdentity{}
+		for _, identity := range identities {
+			bySlug[identity.Slug] = identity
+		}
+
+		// Users keep their free text as the custom text of the self-described identity
+		// when it matches no identity of the list
+		var genders []string
+		err := tx.Model(&models.User{}).Where("gender_identity_id IS NULL AND gender <> ''").Distinct().Pluck("gender", &genders).Error
+		if err != nil {
+			return err
+		}
+		for _, gender := range genders {
+			slug, custom := models.LegacyGenderSlug(gender, identities)
+			identity := bySlug[slug]
+			err := tx.Model(&models.User{}).Where("gender_identity_id IS NULL AND gender = ?", gender).Updates(map[string]interface{}{
+				"gender":             models.GenderLabel(identity, custom),
+				"gender_identity_id": identity.ID,
+				"gender_group":       identity.MatchGroup,
+				"gender_custom":      custom,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// The genders of interest become match groups, values matching no identity
+		// are dropped like the API rejects them instead of landing in the nonbinary group
+		var preferences []models.DiscoveryPreference
+		return tx.Where("JSON_LENGTH(genders) > 0").FindInBatches(&preferences, 500, func(batch *gorm.DB, _ int) error {
+			for _, preference := range preferences {
+				groups := []string{}
+				for _, gender := range preference.Genders {
+					group := strings.ToLower(strings.TrimSpace(gender))
+					if !slices.Contains(models.GenderGroups, group) {
+						slug, custom := models.LegacyGenderSlug(gender, identities)
+						if custom != "" {
+							fmt.Printf("Dropping unknown gender of interest %q of the preferences of user %d\n", gender, preference.UserID)
+							continue
+						}
+						group = bySlug[slug].MatchGroup
+					}
+					if !slices.Contains(groups, group) {
+						groups = append(groups, group)
+					}
+				}
+
+				if !slices.Equal(groups, preference.Genders) {
+					preference.Genders = groups
+					if err := tx.Save(&preference).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}).Error
+	})
+}
+
+// seedProfileCatalog adds the default prompts and interests missing from the database
+// Prompts changed by the admins are left as they are
+func seedProfileCatalog(db *gorm.DB) error {
+	prompts := slices.Clone(models.DefaultPrompts)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&prompts).Error; err != nil {
+		return err
+	}
+
+	interests := slices.Clone(models.DefaultInterests)
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&interests).Error
+}
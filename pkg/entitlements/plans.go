@@ -0,0 +1,62 @@
+package entitlements
+
+import (
+	"slices"
+)
+
+// Plans, ordered from the lowest to the highest tier
+const (
+	PlanFree = "free"
+	PlanPlus = "plus"
+	PlanGold = "gold"
+)
+
+// Entitlements are the features unlocked by the plans
+const (
+	// UnlimitedLikes lifts the daily like limit
+	UnlimitedLikes = "unlimited_likes"
+	// SeeLikes reveals who sent the pending likes when the likes inbox only returns their number
+	SeeLikes = "see_likes"
+	// ExtendedDistance lifts the maximum discover distance
+	ExtendedDistance = "extended_distance"
+)
+
+// Plans lists the plans by tier
+var Plans = []string{PlanFree, PlanPlus, PlanGold}
+
+// planEntitlements maps every plan to the entitlements it unlocks
+var planEntitlements = map[string][]string{
+	PlanFree: {},
+	PlanPlus: {UnlimitedLikes, ExtendedDistance},
+	PlanGold: {UnlimitedLikes, SeeLikes, ExtendedDistance},
+}
+
+// IsPlan reports whether the name is a known plan
+func IsPlan(name string) bool {
+	_, ok := planEntitlements[name]
+	return ok
+}
+
+// tier returns the rank of the plan, higher plans unlock more entitlements
+func tier(plan string) int {
+	return slices.Index(Plans, plan)
+}
+
+// Set is the plan of a user along with the entitlements it unlocks
+type Set struct {
+	Plan         string   `json:"plan"`
+	Entitlements []string `json:"entitlements"`
+}
+
+// ForPlan returns the entitlements unlocked by the plan, unknown plans unlock nothing
+func ForPlan(plan string) Set {
+	if !IsPlan(plan) {
+		plan = PlanFree
+	}
+	return Set{Plan: plan, Entitlements: slices.Clone(planEntitlements[plan])}
+}
+
+// Has reports whether the entitlement is unlocked
+func (s Set) Has(entitlement string) bool {
+	return slices.Contains(s.Entitlements, entitlement)
+}
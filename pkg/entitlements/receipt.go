@@ -0,0 +1,88 @@
+package entitlements
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidReceipt = errors.New("invalid receipt")
+
+// Purchase is a plan or a pack of credits bought in an app store, as described by a verified receipt
+// Plan is empty for credit packs and Credits is zero for plans
+type Purchase struct {
+	TransactionID string
+	Plan          string
+	StartsAt      time.Time
+	EndsAt        time.Time
+	Credits       int64
+}
+
+// CreditPacks maps the credit packs sold in the app stores to the credits they hold,
+// receipts for any other product are rejected
+var CreditPacks = map[string]int64{
+	"credits_100":  100,
+	"credits_500":  500,
+	"credits_1200": 1200,
+}
+
+// ReceiptVerifier checks a purchase receipt against the app store of the platform
+type ReceiptVerifier interface {
+	Verify(platform string, receipt string) (Purchase, error)
+}
+
+// MockReceiptVerifier stands in for the app stores in development and tests
+// Receipts are written as "<plan>:<transaction ID>" and grant the plan for Duration,
+// or as "<credit pack>:<transaction ID>" for one of the CreditPacks
+type MockReceiptVerifier struct {
+	Duration time.Duration
+	Now      func() time.Time
+}
+
+func NewMockReceiptVerifier() *MockReceiptVerifier {
+	return &MockReceiptVerifier{Duration: 30 * 24 * time.Hour, Now: time.Now}
+}
+
+func (v *MockReceiptVerifier) Verify(platform string, receipt string) (Purchase, error) {
+	product, transactionID, found := strings.Cut(receipt, ":")
+	if !found || transactionID == "" {
+		return Purchase{}, fmt.Errorf("%w: %s", ErrInvalidReceipt, receipt)
+	}
+
+	now := v.Now()
+	if credits, isPack := CreditPacks[product]; isPack {
+		return Purchase{TransactionID: platform + ":" + transactionID, StartsAt: now, Credits: credits}, nil
+	}
+
+	plan := product
+	if plan == PlanFree || !IsPlan(plan) {
+		return Purchase{}, fmt.Errorf("%w: %s", ErrInvalidReceipt, receipt)
+	}
+	return Purchase{
+		TransactionID: platform + ":" + transactionID,
+		Plan:          plan,
+		StartsAt:      now,
+		EndsAt:        now.Add(v.Duration),
+	}, nil
+}
+
+// RejectingReceiptVerifier rejects every receipt, it's the default verifier so purchases
+// fail closed until an app store verifier is installed
+type RejectingReceiptVerifier struct{}
+
+func (v RejectingReceiptVerifier) Verify(platform string, receipt string) (Purchase, error) {
+	return Purchase{}, fmt.Errorf("%w: no receipt verifier configured for %s", ErrInvalidReceipt, platform)
+}
+
+var verifier ReceiptVerifier = RejectingReceiptVerifier{}
+
+// SetVerifier replaces the receipt verifier, the mock one in development
+// and the app store ones once they are integrated
+func SetVerifier(v ReceiptVerifier) {
+	verifier = v
+}
+
+func GetVerifier() ReceiptVerifier {
+	return verifier
+}
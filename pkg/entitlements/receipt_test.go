@@ -0,0 +1,85 @@
+package entitlements
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMockReceiptVerifierPlans(t *testing.T) {
+	now := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+	verifier := &MockReceiptVerifier{Duration: 30 * 24 * time.Hour, Now: func() time.Time { return now }}
+
+	tests := []struct {
+		receipt string
+		want    Purchase
+		wantErr bool
+	}{
+		{"gold:tx-1", Purchase{TransactionID: "ios:tx-1", Plan: PlanGold, StartsAt: now, EndsAt: now.Add(30 * 24 * time.Hour)}, false},
+		{"plus:tx-2", Purchase{TransactionID: "ios:tx-2", Plan: PlanPlus, StartsAt: now, EndsAt: now.Add(30 * 24 * time.Hour)}, false},
+		{"free:tx-3", Purchase{}, true},
+		{"platinum:tx-4", Purchase{}, true},
+		{"gold:", Purchase{}, true},
+		{"gold", Purchase{}, true},
+		{"", Purchase{}, true},
+	}
+
+	for _, test := range tests {
+		got, err := verifier.Verify("ios", test.receipt)
+		if test.wantErr {
+			if !errors.Is(err, ErrInvalidReceipt) {
+				t.Errorf("Verify(%q) error = %v, want ErrInvalidReceipt", test.receipt, err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("Verify(%q) = %+v, %v, want %+v", test.receipt, got, err, test.want)
+		}
+	}
+}
+
+func TestMockReceiptVerifierCreditPacks(t *testing.T) {
+	now := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+	verifier := &MockReceiptVerifier{Duration: 30 * 24 * time.Hour, Now: func() time.Time { return now }}
+
+	tests := []struct {
+		receipt     string
+		wantCredits int64
+		wantErr     bool
+	}{
+		{"credits_100:GPA.1", 100, false},
+		{"credits_500:GPA.2", 500, false},
+		{"credits_1200:GPA.3", 1200, false},
+		{"credits_1000000:GPA.4", 0, true},
+		{"credits_50:GPA.5", 0, true},
+		{"credits_-100:GPA.6", 0, true},
+		{"credits_100:", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := verifier.Verify("android", test.receipt)
+		if test.wantErr {
+			if !errors.Is(err, ErrInvalidReceipt) {
+				t.Errorf("Verify(%q) error = %v, want ErrInvalidReceipt", test.receipt, err)
+			}
+			continue
+		}
+		if err != nil || got.Credits != test.wantCredits || got.Plan != "" || !got.StartsAt.Equal(now) {
+			t.Errorf("Verify(%q) = %+v, %v, want %d credits", test.receipt, got, err, test.wantCredits)
+		}
+	}
+}
+
+func TestRejectingReceiptVerifier(t *testing.T) {
+	for _, receipt := range []string{"gold:tx-1", "plus:tx-2", "credits_100:tx-3"} {
+		if _, err := (RejectingReceiptVerifier{}).Verify("android", receipt); !errors.Is(err, ErrInvalidReceipt) {
+			t.Errorf("Verify(%q) error = %v, want ErrInvalidReceipt", receipt, err)
+		}
+	}
+}
+
+func TestDefaultVerifierRejectsReceipts(t *testing.T) {
+	if _, err := GetVerifier().Verify("ios", "gold:tx-1"); !errors.Is(err, ErrInvalidReceipt) {
+		t.Errorf("default verifier accepted a mock receipt, error = %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package entitlements
+
+import (
+	"time"
+
+	"dating-app/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// activeSubscriptions returns the subscriptions of the user that currently grant a plan
+func activeSubscriptions(db *gorm.DB, userID uint64, now time.Time) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	err := db.Where("user_id = ? AND status = ? AND starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)",
+		userID, models.SubscriptionStatusActive, now, now).
+		Order("starts_at DESC").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// Current returns the subscription granting the highest plan to the user, or nil on the free plan
+func Current(db *gorm.DB, userID uint64, now time.Time) (*models.Subscription, error) {
+	subscriptions, err := activeSubscriptions(db, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var current *models.Subscription
+	for i := range subscriptions {
+		if current == nil || tier(subscriptions[i].Plan) > tier(current.Plan) {
+			current = &subscriptions[i]
+		}
+	}
+	return current, nil
+}
+
+// Load returns the entitlements of the user, the highest of their active plans wins
+func Load(db *gorm.DB, userID uint64, now time.Time) (Set, error) {
+	current, err := Current(db, userID, now)
+	if err != nil || current == nil {
+		return ForPlan(PlanFree), err
+	}
+	return ForPlan(current.Plan), nil
+}
@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/ledger"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Lifetime of the tokens handed to admins impersonating a user
+const impersonationDuration = time.Hour
+
+type AdminUserSummaryResponse struct {
+	ID     uint64 `json:"id"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Role   string `json:"role"`
+}
+
+type AdminUserDetailsResponse struct {
+	ID                    uint64     `json:"id"`
+	Email                 string     `json:"email"`
+	Name                  string     `json:"name"`
+	Gender                string     `json:"gender"`
+	GenderGroup           string     `json:"genderGroup"`
+	ShowGender            bool       `json:"showGender"`
+	Age                   int        `json:"age"`
+	Latitude              float64    `json:"latitude"`
+	Longitude             float64    `json:"longitude"`
+	TotalLikesReceived    int        `json:"totalLikesReceived"`
+	TotalDislikesReceived int        `json:"totalDislikesReceived"`
+	AttractivenessScore   float64    `json:"attractivenessScore"`
+	Status                string     `json:"status"`
+	SuspendedUntil        *time.Time `json:"suspendedUntil"`
+	Role                  string     `json:"role"`
+	ShadowBanned          bool       `json:"shadowBanned"`
+	DailyLikeLimit        int        `json:"dailyLikeLimit"`
+	Plan                  string     `json:"plan"`
+	CreditBalance         int64      `json:"creditBalance"`
+	LoggedIn              bool       `json:"loggedIn"`
+	SwipesGiven           int64      `json:"swipesGiven"`
+	LikesGiven            int64      `json:"likesGiven"`
+	SwipesReceived        int64      `json:"swipesReceived"`
+	MatchCount            int64      `json:"matchCount"`
+}
+
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	UserID    uint64    `json:"userID"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func SearchUsers(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	page, pageSize, err := utils.ParsePagination(r, 20, 100)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	// The search term is matched against the email and the name,
+	// and against the ID when it's a number
+	query := core.GetDb().Model(&models.User{})
+	if term := strings.TrimSpace(r.URL.Query().Get("q")); term != "" {
+		pattern := "%" + term + "%"
+		if userID, err := strconv.ParseUint(term, 10, 64); err == nil {
+			query = query.Where("id = ? OR email LIKE ? OR name LIKE ?", userID, pattern, pattern)
+		} else {
+			query = query.Where("email LIKE ? OR name LIKE ?", pattern, pattern)
+		}
+	}
+
+	users := []AdminUserSummaryResponse{}
+	err = query.Order("id ASC").Offset((page - 1) * pageSize).Limit(pageSize).Scan(&users).Error
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error searching users"))
+		return
+	}
+
+	response := struct {
+		Results []AdminUserSummaryResponse `json:"results"`
+	}{
+		Results: users,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+func GetAdminUser(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	user, err := getPathUser(r)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	response, err := getAdminUserDetails(user)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error counting user activity"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// getAdminUserDetails builds the full profile of the user along with their activity counts
+func getAdminUserDetails(user models.User) (AdminUserDetailsResponse, error) {
+	response := AdminUserDetailsResponse{
+		ID:                    user.ID,
+		Email:                 user.Email,
+		Name:                  user.Name,
+		Gender:                user.Gender,
+		GenderGroup:           user.GenderGroup,
+		ShowGender:            user.ShowGender,
+		Age:                   user.Age,
+		Latitude:              user.Latitude,
+		Longitude:             user.Longitude,
+		TotalLikesReceived:    user.TotalLikesReceived,
+		TotalDislikesReceived: user.TotalDislikesReceived,
+		AttractivenessScore:   user.AttractivenessScore,
+		Status:                user.Status,
+		SuspendedUntil:        user.SuspendedUntil,
+		Role:                  user.Role,
+		ShadowBanned:          user.ShadowBanned,
+	}
+
+	userEntitlements, err := getEntitlements(user.ID)
+	if err != nil {
+		return response, err
+	}
+	response.Plan = userEntitlements.Plan
+	response.DailyLikeLimit = dailyLikeLimit(user, userEntitlements)
+
+	response.CreditBalance, err = ledger.Balance(core.GetDb(), user.ID)
+	if err != nil {
+		return response, err
+	}
+
+	var tokenCount int64
+	counts := []struct {
+		query *gorm.DB
+		count *int64
+	}{
+		{core.GetDb().Model(&models.Token{}).Where("user_id = ?", user.ID), &tokenCount},
+		{core.GetDb().Model(&models.Swipe{}).Where("swiper_id = ?", user.ID), &response.SwipesGiven},
+		{core.GetDb().Model(&models.Swipe{}).Where("swiper_id = ? AND swipe_type = ?", user.ID, "YES"), &response.LikesGiven},
+		{core.GetDb().Model(&models.Swipe{}).Where("target_id = ?", user.ID), &response.SwipesReceived},
+		{core.GetDb().Model(&models.Match{}).Where("user1_id = ? OR user2_id = ?", user.ID, user.ID), &response.MatchCount},
+	}
+	for _, count := range counts {
+		if err := count.query.Count(count.count).Error; err != nil {
+			return response, err
+		}
+	}
+	response.LoggedIn = tokenCount > 0
+
+	return response, nil
+}
+
+func ForceLogoutUser(w http.ResponseWriter, r *http.Request) {
+	adminUserAction(w, r, models.ModerationActionLogout, func(tx *gorm.DB, user models.User, payload adminActionPayload) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.Token{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND expires_at > ?", user.ID, time.Now()).Delete(&models.Impersonation{}).Error
+	})
+}
+
+func ResetUserScore(w http.ResponseWriter, r *http.Request) {
+	adminUserAction(w, r, models.ModerationActionResetScore, func(tx *gorm.DB, user models.User, payload adminActionPayload) error {
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"total_likes_received":    0,
+			"total_dislikes_received": 0,
+			"attractiveness_score":    0,
+		}).Error
+	})
+}
+
+func SuspendUser(w http.ResponseWriter, r *http.Request) {
+	adminUserAction(w, r, models.ModerationActionSuspend, func(tx *gorm.DB, user models.User, payload adminActionPayload) error {
+		if payload.Days < 0 {
+			return utils.NewAppError(http.StatusBadRequest, "days can't be negative")
+		}
+		return applyModerationAction(tx, user.ID, models.ModerationActionSuspend, payload.Days)
+	})
+}
+
+func ReinstateUser(w http.ResponseWriter, r *http.Request) {
+	adminUserAction(w, r, models.ModerationActionReinstate, func(tx *gorm.DB, user models.User, payload adminActionPayload) error {
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"status":          models.UserStatusActive,
+			"suspended_until": nil,
+		}).Error
+	})
+}
+
+func ShadowBanUser(w http.ResponseWriter, r *http.Request) {
+	adminUserAction(w, r, models.ModerationActionShadowBan, func(tx *gorm.DB, user models.User, payload adminActionPayload) error {
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).Update("shadow_banned", true).Error
+	})
+}
+
+func UnshadowBanUser(w http.ResponseWriter, r *http.Request) {
+	adminUserAction(w, r, models.ModerationActionUnshadowBan, func(tx *gorm.DB, user models.User, payload adminActionPayload) error {
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).Update("shadow_banned", false).Error
+	})
+}
+
+// SetUserLikeLimit overrides the daily like limit of the user, a null limit restores the default
+func SetUserLikeLimit(w http.ResponseWriter, r *http.Request) {
+	adminUserAction(w, r, models.ModerationActionLikeLimit, func(tx *gorm.DB, user models.User, payload adminActionPayload) error {
+		if payload.DailyLikeLimit != nil && *payload.DailyLikeLimit < 0 {
+			return utils.NewAppError(http.StatusBadRequest, "dailyLikeLimit can't be negative")
+		}
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).Update("daily_like_limit", payload.DailyLikeLimit).Error
+	})
+}
+
+func ImpersonateUser(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var impersonatePayload struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&impersonatePayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+	reason := strings.TrimSpace(impersonatePayload.Reason)
+	if reason == "" {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "A reason is required to impersonate a user"))
+		return
+	}
+
+	user, err := getPathUser(r)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	// Impersonating staff members would allow escalating privileges
+	if user.Role != models.RoleUser {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusForbidden, "Cannot impersonate staff members"))
+		return
+	}
+
+	tokenValue, err := generateTokenValue()
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error generating impersonation token"))
+		return
+	}
+
+	impersonation := models.Impersonation{
+		AdminID:   contextUser.ID,
+		UserID:    user.ID,
+		Reason:    reason,
+		Token:     tokenValue,
+		ExpiresAt: time.Now().Add(impersonationDuration),
+	}
+	err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&impersonation).Error; err != nil {
+			return err
+		}
+		return recordModerationAction(tx, nil, user.ID, contextUser.ID, models.ModerationActionImpersonate, reason)
+	})
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating impersonation token"))
+		return
+	}
+
+	response := ImpersonationResponse{
+		Token:     impersonation.Token,
+		UserID:    user.ID,
+		ExpiresAt: impersonation.ExpiresAt,
+	}
+	utils.WriteSuccessResponse(w, http.StatusCreated, response)
+}
+
+// adminActionPayload is the optional body of the admin actions
+type adminActionPayload struct {
+	Note           string `json:"note"`
+	Days           int    `json:"days"`
+	DailyLikeLimit *int   `json:"dailyLikeLimit"`
+	Plan           string `json:"plan"`
+	Credits        int64  `json:"credits"`
+	PurchaseID     string `json:"purchaseID"`
+}
+
+// adminUserAction runs an admin action on the user from the request path and records it
+// in the moderation audit trail along with the optional note sent in the body
+func adminUserAction(w http.ResponseWriter, r *http.Request, action string, apply func(tx *gorm.DB, user models.User, payload adminActionPayload) error) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var actionPayload adminActionPayload
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&actionPayload); err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+			return
+		}
+	}
+
+	user, err := getPathUser(r)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := apply(tx, user, actionPayload); err != nil {
+			return err
+		}
+		return recordModerationAction(tx, nil, user.ID, contextUser.ID, action, actionPayload.Note)
+	})
+	if err != nil {
+		writeTransactionError(w, err, fmt.Sprintf("Error applying %s", action))
+		return
+	}
+
+	// Return the updated user
+	user, err = getPathUser(r)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+	response, err := getAdminUserDetails(user)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error counting user activity"))
+		return
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+type UserRoleResponse struct {
+	ID   uint64 `json:"id"`
+	Role string `json:"role"`
+}
+
+func UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP PUT Method
+	if r.Method != http.MethodPut {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var rolePayload struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&rolePayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+
+	switch rolePayload.Role {
+	case models.RoleUser, models.RoleModerator, models.RoleAdmin:
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Role must be one of: user, moderator, admin"))
+		return
+	}
+
+	user, err := getPathUser(r)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	// Prevent admins from locking everyone out by demoting themselves
+	if user.ID == contextUser.ID && rolePayload.Role != models.RoleAdmin {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Cannot remove your own admin role"))
+		return
+	}
+
+	if err := core.GetDb().Model(&user).Update("role", rolePayload.Role).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error updating user role"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, UserRoleResponse{ID: user.ID, Role: rolePayload.Role})
+}
+
+// getPathUser loads the user identified by the "id" path parameter
+func getPathUser(r *http.Request) (models.User, error) {
+	var user models.User
+
+	userID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return user, utils.NewAppError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	err = core.GetDb().Omit("password").First(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, utils.NewAppError(http.StatusNotFound, "User not found")
+	}
+	if err != nil {
+		return user, utils.NewAppError(http.StatusInternalServerError, "Error retrieving user")
+	}
+
+	return user, nil
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlockedUserResponse struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	BlockedAt time.Time `json:"blockedAt"`
+}
+
+// Blocks serves the block list of the user
+// GET lists the blocked users and POST blocks a user
+func Blocks(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet:
+		listBlocks(w, r)
+	case http.MethodPost:
+		createBlock(w, r)
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+	}
+}
+
+func createBlock(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	var blockPayload struct {
+		UserID uint64 `json:"userID"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&blockPayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+
+	if blockPayload.UserID == contextUser.ID {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Cannot block yourself"))
+		return
+	}
+
+	var blockedUser models.User
+	if err := core.GetDb().First(&blockedUser, blockPayload.UserID).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "User not found"))
+		return
+	}
+
+	block := models.Block{
+		BlockerID: contextUser.ID,
+		BlockedID: blockedUser.ID,
+	}
+	err := core.GetDb().Transaction(func(tx *gorm.DB) error {
+		// Blocking an already blocked user is a no-op
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+			return err
+		}
+
+		// Soft delete the match between the two users, its conversation becomes read-only
+		return tx.Where("(user1_id = ? AND user2_id = ?) OR (user1_id = ? AND user2_id = ?)", contextUser.ID, blockedUser.ID, blockedUser.ID, contextUser.ID).
+			Delete(&models.Match{}).Error
+	})
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error blocking user"))
+		return
+	}
+
+	response := BlockedUserResponse{
+		ID:        blockedUser.ID,
+		Name:      blockedUser.Name,
+		BlockedAt: block.CreatedAt,
+	}
+	utils.WriteSuccessResponse(w, http.StatusCreated, response)
+}
+
+func listBlocks(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	blockedUsers := []BlockedUserResponse{}
+	err := core.GetDb().Table("blocks").
+		Select("users.id, users.name, blocks.created_at AS blocked_at").
+		Joins("JOIN users ON users.id = blocks.blocked_id").
+		Where("blocks.blocker_id = ?", contextUser.ID).
+		Order("blocks.created_at DESC").
+		Scan(&blockedUsers).Error
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching blocked users"))
+		return
+	}
+
+	response := struct {
+		Results []BlockedUserResponse `json:"results"`
+	}{
+		Results: blockedUsers,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+func DeleteBlock(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP DELETE Method
+	if r.Method != http.MethodDelete {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	blockedID, err := strconv.ParseUint(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid user ID"))
+		return
+	}
+
+	// Unblocking doesn't restore the deleted match
+	result := core.GetDb().Where("blocker_id = ? AND blocked_id = ?", contextUser.ID, blockedID).Delete(&models.Block{})
+	if result.Error != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error unblocking user"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "Block not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Boosts are limited per rolling week
+const boostLimitPeriod = 7 * 24 * time.Hour
+
+// The likes received during a boost are compared with the likes received in the week before it
+const boostBaselinePeriod = 7 * 24 * time.Hour
+
+// Boosted profiles rank at least like an average profile, otherwise boosting
+// a new profile without any likes yet would have no effect
+const minBoostedScore = 0.5
+
+type BoostLimitDetails struct {
+	Limit   int       `json:"limit"`
+	ResetAt time.Time `json:"resetAt"`
+}
+
+type BoostStatsResponse struct {
+	Boost         models.Boost `json:"boost"`
+	Active        bool         `json:"active"`
+	LikesReceived int64        `json:"likesReceived"`
+	// BaselineLikes is the average number of likes received over the duration
+	// of the boost in the week before it
+	BaselineLikes float64 `json:"baselineLikes"`
+	// Lift is the ratio between the likes received and the baseline, null without a baseline
+	Lift *float64 `json:"lift"`
+}
+
+// Boosts serves the profile boosts of the user
+// GET lists the boosts and POST starts a new one
+func Boosts(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet:
+		listBoosts(w, r)
+	case http.MethodPost:
+		createBoost(w, r)
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+	}
+}
+
+func createBoost(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	now := time.Now()
+	boost := models.Boost{
+		UserID:   contextUser.ID,
+		StartsAt: now,
+		EndsAt:   now.Add(time.Duration(core.AppConfig.BOOST_DURATION_MINUTES) * time.Minute),
+	}
+
+	err := core.GetDb().Transaction(func(tx *gorm.DB) error {
+		// Lock the user so concurrent requests can't go over the limit
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, contextUser.ID).Error; err != nil {
+			return err
+		}
+
+		var boosts []models.Boost
+		err := tx.Where("user_id = ? AND starts_at > ?", contextUser.ID, now.Add(-boostLimitPeriod)).Order("starts_at ASC").Find(&boosts).Error
+		if err != nil {
+			return err
+		}
+
+		for _, previous := range boosts {
+			if previous.IsActive(now) {
+				return utils.NewAppError(http.StatusConflict, "A boost is already active")
+			}
+		}
+
+		limit := core.AppConfig.BOOSTS_PER_WEEK
+		if limit <= 0 {
+			return utils.NewAppError(http.StatusForbidden, "Boosts are not available")
+		}
+		if len(boosts) >= limit {
+			// The oldest boost counted in the limit has to fall out of the period before starting a new one
+			resetAt := boosts[len(boosts)-limit].StartsAt.Add(boostLimitPeriod)
+			return utils.NewAppErrorWithDetails(http.StatusTooManyRequests, "Weekly boost limit reached", BoostLimitDetails{
+				Limit:   limit,
+				ResetAt: resetAt,
+			})
+		}
+
+		return tx.Create(&boost).Error
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error creating boost")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusCreated, boost)
+}
+
+func listBoosts(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	page, pageSize, err := utils.ParsePagination(r, 20, 100)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	boosts := []models.Boost{}
+	err = core.GetDb().Where("user_id = ?", contextUser.ID).
+		Order("starts_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&boosts).Error
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching boosts"))
+		return
+	}
+
+	response := struct {
+		Results []models.Boost `json:"results"`
+	}{
+		Results: boosts,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// GetBoostStats compares the likes received during the boost with the usual likes of the user
+func GetBoostStats(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	boostID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid boost ID"))
+		return
+	}
+
+	var boost models.Boost
+	err = core.GetDb().Where("id = ? AND user_id = ?", boostID, contextUser.ID).First(&boost).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "Boost not found"))
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching boost"))
+		return
+	}
+
+	now := time.Now()
+	response := BoostStatsResponse{Boost: boost, Active: boost.IsActive(now)}
+
+	// An active boost is measured up to now
+	end := boost.EndsAt
+	if end.After(now) {
+		end = now
+	}
+
+	var baselineLikes int64
+	counts := []struct {
+		from, to time.Time
+		count    *int64
+	}{
+		{boost.StartsAt, end, &response.LikesReceived},
+		{boost.StartsAt.Add(-boostBaselinePeriod), boost.StartsAt, &baselineLikes},
+	}
+	for _, count := range counts {
+		err := core.GetDb().Model(&models.Swipe{}).
+			Where("target_id = ? AND swipe_type = ? AND created_at >= ? AND created_at < ?", contextUser.ID, "YES", count.from, count.to).
+			Count(count.count).Error
+		if err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error counting boost likes"))
+			return
+		}
+	}
+
+	// Scale the likes of the week before down to the measured duration of the boost
+	response.BaselineLikes = float64(baselineLikes) * end.Sub(boost.StartsAt).Seconds() / boostBaselinePeriod.Seconds()
+	if response.BaselineLikes > 0 {
+		lift := float64(response.LikesReceived) / response.BaselineLikes
+		response.Lift = &lift
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// getBoostedUserIDs returns which of the users are currently boosted
+func getBoostedUserIDs(userIDs []uint64, now time.Time) (map[uint64]bool, error) {
+	boosted := map[uint64]bool{}
+	if len(userIDs) == 0 {
+		return boosted, nil
+	}
+
+	var boostedIDs []uint64
+	err := core.GetDb().Model(&models.Boost{}).
+		Where("user_id IN ? AND starts_at <= ? AND ends_at > ?", userIDs, now, now).
+		Distinct().
+		Pluck("user_id", &boostedIDs).Error
+	for _, userID := range boostedIDs {
+		boosted[userID] = true
+	}
+	return boosted, err
+}
+
+// boostedScore returns the rank of a boosted profile for a viewer at the given distance
+// Only nearby viewers see the boost
+func boostedScore(score float64, distance float64) float64 {
+	if distance > core.AppConfig.BOOST_RADIUS_KM {
+		return score
+	}
+	return max(score, minBoostedScore) * core.AppConfig.BOOST_MULTIPLIER
+}
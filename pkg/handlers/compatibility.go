@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/matching"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+)
+
+// getInterestWeights loads how many users have each interest along with the weights set by the admins
+func getInterestWeights() (matching.InterestWeights, error) {
+	stats := matching.InterestStats{Counts: map[uint64]int64{}}
+
+	var counts []struct {
+		InterestID uint64
+		Users      int64
+	}
+	err := core.GetDb().Model(&models.UserInterest{}).
+		Select("interest_id, COUNT(*) AS users").
+		Group("interest_id").
+		Scan(&counts).Error
+	if err != nil {
+		return matching.InterestWeights{}, err
+	}
+	for _, count := range counts {
+		stats.Counts[count.InterestID] = count.Users
+	}
+
+	err = core.GetDb().Model(&models.UserInterest{}).Distinct("user_id").Count(&stats.Users).Error
+	if err != nil {
+		return matching.InterestWeights{}, err
+	}
+
+	var interests []models.Interest
+	if err := core.GetDb().Where("weight IS NOT NULL").Find(&interests).Error; err != nil {
+		return matching.InterestWeights{}, err
+	}
+	overrides := map[uint64]float64{}
+	for _, interest := range interests {
+		overrides[interest.ID] = *interest.Weight
+	}
+
+	return matching.NewInterestWeights(core.AppConfig.INTEREST_WEIGHTING, stats, overrides), nil
+}
+
+// getUserInterestIDs returns the interests of the user
+func getUserInterestIDs(userID uint64) ([]uint64, error) {
+	interestIDs := []uint64{}
+	err := core.GetDb().Model(&models.UserInterest{}).Where("user_id = ?", userID).Pluck("interest_id", &interestIDs).Error
+	return interestIDs, err
+}
+
+// interestIDsOf returns the IDs of the interests
+func interestIDsOf(interests []models.Interest) []uint64 {
+	interestIDs := make([]uint64, len(interests))
+	for i, interest := range interests {
+		interestIDs[i] = interest.ID
+	}
+	return interestIDs
+}
+
+// parseMinCompatibility reads the minCompatibility query parameter, 0 when missing
+func parseMinCompatibility(r *http.Request) (float64, error) {
+	value := r.URL.Query().Get("minCompatibility")
+	if value == "" {
+		return 0, nil
+	}
+
+	minCompatibility, err := strconv.ParseFloat(value, 64)
+	if err != nil || minCompatibility < 0 || minCompatibility > 1 {
+		return 0, utils.NewAppError(http.StatusBadRequest, "Invalid minCompatibility parameter, it must be between 0 and 1")
+	}
+	return minCompatibility, nil
+}
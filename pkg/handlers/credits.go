@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/entitlements"
+	"dating-app/pkg/ledger"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type CreditTransactionResponse struct {
+	ID        uint64    `json:"id"`
+	Kind      string    `json:"kind"`
+	Reference string    `json:"reference"`
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CreditsResponse struct {
+	Balance      int64                       `json:"balance"`
+	Transactions []CreditTransactionResponse `json:"transactions"`
+}
+
+type CreditPurchaseResponse struct {
+	Balance     int64                     `json:"balance"`
+	Transaction CreditTransactionResponse `json:"transaction"`
+}
+
+// GetCredits returns the credit balance of the user along with their transactions, newest first
+func GetCredits(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	page, pageSize, err := utils.ParsePagination(r, 20, 100)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	balance, err := ledger.Balance(core.GetDb(), contextUser.ID)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching credits"))
+		return
+	}
+
+	// Only the entries of the user's account are returned, the counterparts are internal
+	transactions := []CreditTransactionResponse{}
+	err = core.GetDb().Table("ledger_entries").
+		Select("ledger_transactions.id, ledger_transactions.kind, ledger_transactions.reference, ledger_entries.amount, ledger_transactions.created_at").
+		Joins("JOIN ledger_transactions ON ledger_transactions.id = ledger_entries.transaction_id").
+		Joins("JOIN ledger_accounts ON ledger_accounts.id = ledger_entries.account_id").
+		Where("ledger_accounts.name = ?", ledger.UserAccount(contextUser.ID)).
+		Order("ledger_transactions.id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&transactions).Error
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching credits"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, CreditsResponse{Balance: balance, Transactions: transactions})
+}
+
+// PurchaseCredits redeems an app store receipt for the credits it was bought for
+// The purchase is keyed by the store transaction ID so redeeming a receipt again is a no-op
+func PurchaseCredits(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var purchasePayload struct {
+		Platform string `json:"platform"`
+		Receipt  string `json:"receipt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&purchasePayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+
+	platform := strings.ToLower(purchasePayload.Platform)
+	if platform != models.PlatformIOS && platform != models.PlatformAndroid {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Platform must be ios or android"))
+		return
+	}
+
+	purchase, err := entitlements.GetVerifier().Verify(platform, purchasePayload.Receipt)
+	if errors.Is(err, entitlements.ErrInvalidReceipt) || (err == nil && purchase.Credits <= 0) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid receipt"))
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadGateway, "Error verifying receipt"))
+		return
+	}
+
+	var transaction models.LedgerTransaction
+	var balance int64
+	err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+		var created bool
+		var err error
+		transaction, created, err = ledger.Credit(tx, contextUser.ID, purchase.Credits, models.LedgerTransactionPurchase, "purchase:"+purchase.TransactionID, purchase.TransactionID)
+		if err != nil {
+			return err
+		}
+
+		balance, err = ledger.Balance(tx, contextUser.ID)
+		if err != nil {
+			return err
+		}
+
+		// A receipt already redeemed must have credited this user
+		if !created && !creditsUser(tx, transaction, contextUser.ID) {
+			return utils.NewAppError(http.StatusConflict, "Receipt already redeemed")
+		}
+		return nil
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error crediting purchase")
+		return
+	}
+
+	response := CreditPurchaseResponse{
+		Balance: balance,
+		Transaction: CreditTransactionResponse{
+			ID:        transaction.ID,
+			Kind:      transaction.Kind,
+			Reference: transaction.Reference,
+			Amount:    purchase.Credits,
+			CreatedAt: transaction.CreatedAt,
+		},
+	}
+	utils.WriteSuccessResponse(w, http.StatusCreated, response)
+}
+
+// GrantUserCredits adds credits to the user, grants sent with a purchaseID are only applied once
+func GrantUserCredits(w http.ResponseWriter, r *http.Request) {
+	adminUserAction(w, r, models.ModerationActionGrantCredits, func(tx *gorm.DB, user models.User, payload adminActionPayload) error {
+		if payload.Credits <= 0 {
+			return utils.NewAppError(http.StatusBadRequest, "credits must be positive")
+		}
+
+		idempotencyKey := ""
+		if payload.PurchaseID != "" {
+			idempotencyKey = "grant:" + payload.PurchaseID
+		}
+		transaction, created, err := ledger.Credit(tx, user.ID, payload.Credits, models.LedgerTransactionGrant, idempotencyKey, payload.Note)
+		if err != nil {
+			return err
+		}
+		if !created && !creditsUser(tx, transaction, user.ID) {
+			return utils.NewAppError(http.StatusConflict, "Purchase already credited to another user")
+		}
+		return nil
+	})
+}
+
+// payExtraLike spends the credits paying for a like over the daily limit, within the swipe transaction
+func payExtraLike(tx *gorm.DB, userID uint64, swipeID uint64) error {
+	cost := core.AppConfig.CREDITS_PER_EXTRA_LIKE
+	if cost <= 0 {
+		return errDailyLikeLimitReached
+	}
+
+	_, err := ledger.Spend(tx, userID, int64(cost), fmt.Sprintf("swipe:%d", swipeID))
+	if errors.Is(err, ledger.ErrInsufficientCredits) {
+		return errDailyLikeLimitReached
+	}
+	return err
+}
+
+// creditsUser reports whether the ledger transaction credited the account of the user
+func creditsUser(tx *gorm.DB, transaction models.LedgerTransaction, userID uint64) bool {
+	var account models.LedgerAccount
+	if err := tx.Where("name = ?", ledger.UserAccount(userID)).First(&account).Error; err != nil {
+		return false
+	}
+	return slices.ContainsFunc(transaction.Entries, func(entry models.LedgerEntry) bool {
+		return entry.AccountID == account.ID
+	})
+}
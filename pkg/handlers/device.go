@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/notify"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func RegisterDevice(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var devicePayload struct {
+		Platform string `json:"platform"`
+		Token    string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&devicePayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+
+	platform := strings.ToLower(devicePayload.Platform)
+	if platform != models.PlatformIOS && platform != models.PlatformAndroid {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Platform must be ios or android"))
+		return
+	}
+	if devicePayload.Token == "" || len(devicePayload.Token) > 255 {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid device token"))
+		return
+	}
+
+	// A push token belongs to a single device, when another user logs in
+	// on the same device the token moves to the new user
+	device := models.Device{
+		UserID:   contextUser.ID,
+		Platform: platform,
+		Token:    devicePayload.Token,
+	}
+	err := core.GetDb().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "updated_at"}),
+	}).Create(&device).Error
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error registering device"))
+		return
+	}
+
+	// The ID is not returned by MySQL when the row was updated
+	if err := core.GetDb().Where("token = ?", device.Token).First(&device).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error registering device"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusCreated, device)
+}
+
+func DeleteDevice(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP DELETE Method
+	if r.Method != http.MethodDelete {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	deviceID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid device ID"))
+		return
+	}
+
+	result := core.GetDb().Where("id = ? AND user_id = ?", deviceID, contextUser.ID).Delete(&models.Device{})
+	if result.Error != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error deleting device"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "Device not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// NotificationPreferences serves the push notification settings of the user
+// GET returns the current settings and PUT replaces them
+func NotificationPreferences(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	switch r.Method {
+	case http.MethodGet:
+		preference, err := getNotificationPreference(contextUser.ID)
+		if err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching notification preferences"))
+			return
+		}
+		utils.WriteSuccessResponse(w, http.StatusOK, preference)
+	case http.MethodPut:
+		preference := models.DefaultNotificationPreference(contextUser.ID)
+		if err := json.NewDecoder(r.Body).Decode(&preference); err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+			return
+		}
+		preference.UserID = contextUser.ID
+
+		if _, err := notify.ParseClock(preference.QuietHoursStart); err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "quietHoursStart must use the HH:MM format"))
+			return
+		}
+		if _, err := notify.ParseClock(preference.QuietHoursEnd); err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "quietHoursEnd must use the HH:MM format"))
+			return
+		}
+		if _, err := time.LoadLocation(preference.Timezone); err != nil || preference.Timezone == "" {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Unknown timezone: %s", preference.Timezone)))
+			return
+		}
+
+		if err := core.GetDb().Save(&preference).Error; err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error saving notification preferences"))
+			return
+		}
+		utils.WriteSuccessResponse(w, http.StatusOK, preference)
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+	}
+}
+
+// getNotificationPreference returns the stored settings of the user or the defaults
+func getNotificationPreference(userID uint64) (models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	err := core.GetDb().Where("user_id = ?", userID).First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DefaultNotificationPreference(userID), nil
+	}
+	return preference, err
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"time"
 
 	"dating-app/pkg/core"
+	"dating-app/pkg/matching"
 	"dating-app/pkg/models"
 	"dating-app/pkg/utils"
 )
@@ -13,10 +15,26 @@ import (
 type PotentialMatchesResponse struct {
 	ID                  uint64  `json:"id"`
 	Name                string  `json:"name"`
-	Gender              string  `json:"gender"`
+	Gender              string  `json:"gender,omitempty"`
 	Age                 int     `json:"age"`
 	DistanceFromMe      float64 `json:"distanceFromMe"`
 	AttractivenessScore float64 `json:"attractivenessScore"`
+	// Compatibility is the weighted overlap of the interests of the viewer and the user, between 0 and 1
+	Compatibility float64 `json:"compatibility"`
+	// MatchPercentage compares the questionnaire answers of the viewer and the user,
+	// null when they have no answered question in common
+	MatchPercentage *int `json:"matchPercentage"`
+	// Photos of the user in their order, isPrimary marks the one shown first
+	Photos    []PhotoResponse         `json:"photos"`
+	Bio       string                  `json:"bio"`
+	Prompts   []ProfilePromptResponse `json:"prompts"`
+	Interests []models.Interest       `json:"interests"`
+	// rank orders the results, it's the attractiveness score raised by active boosts, the compatibility
+	// and the predicted chance of a mutual like
+	rank float64
+	// matchesPreferences is set when the user fits every preference of the viewer,
+	// including the ones that aren't dealbreakers
+	matchesPreferences bool
 }
 
 func GetPotentialMatches(w http.ResponseWriter, r *http.Request) {
@@ -33,33 +51,35 @@ func GetPotentialMatches(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the query parameters
-	minAge := r.URL.Query().Get("minAge")
-	maxAge := r.URL.Query().Get("maxAge")
-	gender := r.URL.Query().Get("gender")
+	// Keep track of the discover requests for the spam detector, leaving out the ones of admins impersonating the user
+	if _, impersonating := r.Context().Value(core.ImpersonationContextKey).(models.Impersonation); !impersonating {
+		recordDiscoverFetch(contextUser.ID)
+	}
+
+	// The stored preferences of the user, overridden by the query parameters
+	preference, err := resolveDiscoveryPreference(r, contextUser)
+	if err != nil {
+		writeTransactionError(w, err, "Error fetching discovery preferences")
+		return
+	}
+	minCompatibility, err := parseMinCompatibility(r)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
 
 	// Fetch all users from the database
 	users := []models.User{}
 
-	// Exclude the profiles the user matched or swiped on.
+	// Exclude the profiles the user matched or swiped on and the profiles hidden from the user.
 	// Exclude the user's own profile from coming up in the results
-	excludedIDs := []uint64{contextUser.ID}
-	swipedUserIDs := getSwipedUserIDs(contextUser.ID)
-	excludedIDs = append(excludedIDs, swipedUserIDs...)
-	query := core.GetDb().Omit("password", "email", "Token").Not("id IN (?)", excludedIDs)
+	query := core.GetDb().Omit("password", "email", "Token").
+		Where("users.id <> ?", contextUser.ID).
+		Scopes(visibleTo(contextUser.ID, "users.id"), notSwipedBy(contextUser.ID, "users.id"))
 
 	// Apply filters
-	if minAge != "" {
-		query = query.Where("age >= ?", minAge)
-	}
-
-	if maxAge != "" {
-		query = query.Where("age <= ?", maxAge)
-	}
-
-	if gender != "" {
-		query = query.Where("gender = ?", gender)
-	}
+	// Only the users whose preferences the user fits as well are shown
+	query = query.Scopes(dealbreakersFilter(preference, contextUser), mutualPreferenceFilter(contextUser))
 
 	// Execute the query
 	result := query.Find(&users)
@@ -68,28 +88,99 @@ func GetPotentialMatches(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userIDs := make([]uint64, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	boosted, err := getBoostedUserIDs(userIDs, time.Now())
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching users"))
+		return
+	}
+	photos, err := getPhotos(userIDs)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching photos"))
+		return
+	}
+	profiles, err := getProfiles(users)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching profiles"))
+		return
+	}
+	viewerInterestIDs, err := getUserInterestIDs(contextUser.ID)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching interests"))
+		return
+	}
+	interestWeights, err := getInterestWeights()
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching interests"))
+		return
+	}
+	matchPercentages, err := getMatchPercentages(contextUser, users)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error computing match percentages"))
+		return
+	}
+	recommendationScores, err := getRecommendationScores(contextUser.ID, userIDs)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error computing recommendations"))
+		return
+	}
+
 	// Convert User slices to PotentialMatchesResponse slices
 	// Used as a data transfer object to omit Token and Password fields
-	potentialMatches := make([]PotentialMatchesResponse, len(users))
-	for i, user := range users {
+	potentialMatches := make([]PotentialMatchesResponse, 0, len(users))
+	for _, user := range users {
 		// Calculate distance for each user and add to the result
 		var distanceFromMe float64 = utils.CalculateDistance(contextUser.Latitude, contextUser.Longitude, user.Latitude, user.Longitude)
-		potentialMatches[i] = PotentialMatchesResponse{
+		potentialMatch := PotentialMatchesResponse{
 			ID:                  user.ID,
 			Name:                user.Name,
-			Gender:              user.Gender,
+			Gender:              publicGender(user),
 			Age:                 user.Age,
 			DistanceFromMe:      distanceFromMe,
 			AttractivenessScore: user.AttractivenessScore,
+			MatchPercentage:     matchPercentages[user.ID],
+			Photos:              photos[user.ID],
+			Bio:                 profiles[user.ID].Bio,
+			Prompts:             profiles[user.ID].Prompts,
+			Interests:           profiles[user.ID].Interests,
+			rank:                user.AttractivenessScore,
+			matchesPreferences:  true,
 		}
+
+		// Users sharing fewer interests than asked for are left out
+		potentialMatch.Compatibility = matching.InterestOverlap(viewerInterestIDs, interestIDsOf(potentialMatch.Interests), interestWeights)
+		if potentialMatch.Compatibility < minCompatibility {
+			continue
+		}
+
+		// Boosted users rank higher for nearby viewers
+		if boosted[user.ID] {
+			potentialMatch.rank = boostedScore(user.AttractivenessScore, distanceFromMe)
+		}
+		potentialMatch.rank += core.AppConfig.COMPATIBILITY_RANK_WEIGHT * potentialMatch.Compatibility
+		potentialMatch.rank += core.AppConfig.RECOMMENDATION_RANK_WEIGHT * recommendationScores[user.ID]
+
+		for _, name := range models.Preferences {
+			if !matchesPreference(preference, name, user, distanceFromMe) {
+				potentialMatch.matchesPreferences = false
+			}
+		}
+
+		potentialMatches = append(potentialMatches, potentialMatch)
 	}
 
-	// Sort users by distance
+	// Sort users matching every preference first, then by rank, then by distance
 	sort.Slice(potentialMatches, func(i, j int) bool {
-		if potentialMatches[i].AttractivenessScore == potentialMatches[j].AttractivenessScore {
+		if potentialMatches[i].matchesPreferences != potentialMatches[j].matchesPreferences {
+			return potentialMatches[i].matchesPreferences
+		}
+		if potentialMatches[i].rank == potentialMatches[j].rank {
 			return potentialMatches[i].DistanceFromMe < potentialMatches[j].DistanceFromMe
 		}
-		return potentialMatches[i].AttractivenessScore > potentialMatches[j].AttractivenessScore
+		return potentialMatches[i].rank > potentialMatches[j].rank
 	})
 
 	// Create a response object with a "results" key
@@ -100,15 +191,3 @@ func GetPotentialMatches(w http.ResponseWriter, r *http.Request) {
 	}
 	utils.WriteSuccessResponse(w, http.StatusOK, response)
 }
-
-func getSwipedUserIDs(userID uint64) []uint64 {
-	var swipes []models.Swipe
-	core.GetDb().Where("swiper_id = ?", userID).Find(&swipes)
-
-	swipedUserIDs := make([]uint64, 0, len(swipes))
-	for _, swipe := range swipes {
-		swipedUserIDs = append(swipedUserIDs, swipe.TargetID)
-	}
-
-	return swipedUserIDs
-}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/media"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type DuplicatePhotoFlagResponse struct {
+	models.DuplicatePhotoFlag
+	// Signed links to the thumbnails of both photos, empty once a photo is deleted
+	PhotoURL        string `json:"photoURL"`
+	MatchedPhotoURL string `json:"matchedPhotoURL"`
+}
+
+// checkDuplicatePhotos looks for the photo on other accounts in the background
+// and flags it for review for every near duplicate found
+func checkDuplicatePhotos(photo models.Photo) {
+	go func() {
+		flags, err := media.FindDuplicatePhotos(core.GetDb(), photo, core.AppConfig.PHOTO_DUPLICATE_MAX_DISTANCE)
+		if err != nil {
+			fmt.Printf("Error looking for duplicate photos: %v\n", err)
+			return
+		}
+
+		for _, flag := range flags {
+			if err := media.RecordDuplicatePhotoFlag(core.GetDb(), flag); err != nil {
+				fmt.Printf("Error recording duplicate photo flag: %v\n", err)
+			}
+		}
+	}()
+}
+
+func ListDuplicatePhotoFlags(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	page, pageSize, err := utils.ParsePagination(r, 20, 100)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	query := core.GetDb().Model(&models.DuplicatePhotoFlag{})
+	if status := r.URL.Query().Get("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if userID := r.URL.Query().Get("userId"); userID != "" {
+		query = query.Where("user_id = ? OR matched_user_id = ?", userID, userID)
+	}
+
+	flags := []models.DuplicatePhotoFlag{}
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&flags).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching duplicate photo flags"))
+		return
+	}
+
+	// Load the photos of the flags to give the reviewers links to compare them
+	photoIDs := []uint64{}
+	for _, flag := range flags {
+		photoIDs = append(photoIDs, flag.PhotoID, flag.MatchedPhotoID)
+	}
+	var photos []models.Photo
+	if len(photoIDs) > 0 {
+		if err := core.GetDb().Where("id IN ?", photoIDs).Find(&photos).Error; err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching photos"))
+			return
+		}
+	}
+	thumbnails := map[uint64]string{}
+	signer := mediaURLSigner()
+	now := time.Now()
+	for _, photo := range photos {
+		if key, exists := photo.Variants["thumb"]; exists {
+			thumbnails[photo.ID] = signer.SignURL(key, now)
+		}
+	}
+
+	results := make([]DuplicatePhotoFlagResponse, len(flags))
+	for i, flag := range flags {
+		results[i] = DuplicatePhotoFlagResponse{
+			DuplicatePhotoFlag: flag,
+			PhotoURL:           thumbnails[flag.PhotoID],
+			MatchedPhotoURL:    thumbnails[flag.MatchedPhotoID],
+		}
+	}
+
+	response := struct {
+		Results []DuplicatePhotoFlagResponse `json:"results"`
+	}{
+		Results: results,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+func ReviewDuplicatePhotoFlag(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	flagID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid flag ID"))
+		return
+	}
+
+	var flag models.DuplicatePhotoFlag
+	err = core.GetDb().First(&flag, flagID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "Duplicate photo flag not found"))
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching duplicate photo flag"))
+		return
+	}
+
+	// Sanctions are applied through the admin user endpoints, reviewing only closes the flag
+	flag.Status = models.DuplicatePhotoFlagStatusReviewed
+	if err := core.GetDb().Save(&flag).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error updating duplicate photo flag"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, flag)
+}
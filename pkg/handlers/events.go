@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/notify"
+	"dating-app/pkg/realtime"
+)
+
+// Number of missed events loaded per batch when replaying them to a reconnecting client
+const replayBatchSize = 500
+
+// emitEvent persists the event in the user event log and publishes it to the open connections
+// Persisting first gives the event an ID that streaming clients can resume from
+func emitEvent(userID uint64, eventType string, data interface{}) {
+	event := realtime.Event{
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		fmt.Printf("Error serializing %s event: %v\n", eventType, err)
+	} else {
+		userEvent := models.UserEvent{
+			UserID:    userID,
+			Type:      eventType,
+			Payload:   string(payload),
+			CreatedAt: event.CreatedAt,
+		}
+		if err := core.GetDb().Create(&userEvent).Error; err != nil {
+			fmt.Printf("Error persisting %s event: %v\n", eventType, err)
+		} else {
+			event.ID = userEvent.ID
+		}
+	}
+
+	realtime.GetBroker().Publish(userID, event)
+}
+
+// sendPushNotification delivers the notification to every device of the user in the background
+// unless the user turned the category off or is in their quiet hours
+func sendPushNotification(userID uint64, notification notify.Notification) {
+	go func() {
+		preference, err := getNotificationPreference(userID)
+		if err != nil {
+			fmt.Printf("Error fetching notification preferences: %v\n", err)
+			return
+		}
+		if !notify.Allows(preference, notification.Category) || notify.InQuietHours(preference, time.Now()) {
+			return
+		}
+
+		var devices []models.Device
+		if err := core.GetDb().Where("user_id = ?", userID).Find(&devices).Error; err != nil {
+			fmt.Printf("Error fetching user devices: %v\n", err)
+			return
+		}
+
+		for _, device := range devices {
+			if err := notify.GetNotifier().Send(device, notification); err != nil {
+				fmt.Printf("Error sending push notification to device %d: %v\n", device.ID, err)
+			}
+		}
+	}()
+}
+
+// replayUserEvents loads the next batch of persisted events of the user sent after the given event ID
+// The event itself must still be in the log, otherwise the events following it may have been
+// deleted by the retention job and realtime.ErrReplayUnavailable is returned
+func replayUserEvents(userID uint64, lastEventID uint64) ([]realtime.Event, error) {
+	var count int64
+	err := core.GetDb().Model(&models.UserEvent{}).Where("id = ? AND user_id = ?", lastEventID, userID).Count(&count).Error
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, realtime.ErrReplayUnavailable
+	}
+
+	var userEvents []models.UserEvent
+	err = core.GetDb().
+		Where("user_id = ? AND id > ?", userID, lastEventID).
+		Order("id ASC").
+		Limit(replayBatchSize).
+		Find(&userEvents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]realtime.Event, len(userEvents))
+	for i, userEvent := range userEvents {
+		events[i] = realtime.Event{
+			ID:        userEvent.ID,
+			Type:      userEvent.Type,
+			Data:      json.RawMessage(userEvent.Payload),
+			CreatedAt: userEvent.CreatedAt,
+		}
+	}
+
+	return events, nil
+}
+
+// publishMatchCreated tells both users about their new match
+func publishMatchCreated(match models.Match) {
+	emitEvent(match.User1ID, realtime.EventMatchCreated, map[string]uint64{"matchID": match.ID, "userID": match.User2ID})
+	emitEvent(match.User2ID, realtime.EventMatchCreated, map[string]uint64{"matchID": match.ID, "userID": match.User1ID})
+
+	notification := notify.Notification{
+		Category: notify.CategoryMatch,
+		Title:    "It's a match!",
+		Body:     "You have a new match, say hi!",
+		Data:     map[string]string{"matchID": fmt.Sprint(match.ID)},
+	}
+	sendPushNotification(match.User1ID, notification)
+	sendPushNotification(match.User2ID, notification)
+}
+
+// publishLikeReceived tells the target user someone liked them
+// The liker stays anonymous when the likes inbox is in count only mode
+func publishLikeReceived(swipe models.Swipe) {
+	data := map[string]uint64{}
+	if canSeeLikers(swipe.TargetID) {
+		data["userID"] = swipe.SwiperID
+	}
+	emitEvent(swipe.TargetID, realtime.EventLikeReceived, data)
+
+	sendPushNotification(swipe.TargetID, notify.Notification{
+		Category: notify.CategoryLike,
+		Title:    "Someone likes you",
+		Body:     "Keep swiping to find out who!",
+	})
+}
+
+// publishProfileUpdated tells the matches of the user their profile changed
+func publishProfileUpdated(userID uint64) {
+	var matches []models.Match
+	if err := core.GetDb().Where("user1_id = ? OR user2_id = ?", userID, userID).Find(&matches).Error; err != nil {
+		fmt.Printf("Error fetching matches: %v\n", err)
+		return
+	}
+
+	for _, match := range matches {
+		emitEvent(otherMatchUserID(match, userID), realtime.EventProfileUpdated, map[string]uint64{"userID": userID})
+	}
+}
+
+// publishMessageCreated sends the message to both users so the other
+// devices of the sender stay in sync as well
+func publishMessageCreated(match models.Match, message models.Message) {
+	emitEvent(match.User1ID, realtime.EventMessageCreated, message)
+	emitEvent(match.User2ID, realtime.EventMessageCreated, message)
+
+	sendPushNotification(otherMatchUserID(match, message.SenderID), notify.Notification{
+		Category: notify.CategoryMessage,
+		Title:    "New message",
+		Body:     "You received a new message",
+		Data:     map[string]string{"matchID": fmt.Sprint(match.ID), "messageID": fmt.Sprint(message.ID)},
+	})
+}
+
+// publishMessageRead sends a read receipt to the other user of the match
+func publishMessageRead(match models.Match, read ReadMarkerResponse) {
+	emitEvent(otherMatchUserID(match, read.UserID), realtime.EventMessageRead, map[string]uint64{
+		"matchID":           match.ID,
+		"userID":            read.UserID,
+		"lastReadMessageID": read.LastReadMessageID,
+	})
+}
+
+// publishTyping tells the other user of the match that the user is typing
+// Typing indicators are short lived so they are not persisted in the event log
+func publishTyping(match models.Match, userID uint64) {
+	realtime.Publish(otherMatchUserID(match, userID), realtime.EventMessageTyping, map[string]uint64{"matchID": match.ID, "userID": userID})
+}
+
+func otherMatchUserID(match models.Match, userID uint64) uint64 {
+	if match.User1ID == userID {
+		return match.User2ID
+	}
+	return match.User1ID
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Longest gender users can write in their own words
+const maxGenderCustomLength = 50
+
+type UserGenderResponse struct {
+	Identity   string `json:"identity"`
+	MatchGroup string `json:"matchGroup"`
+	Custom     string `json:"custom"`
+	Show       bool   `json:"show"`
+	// Gender is shown on the profile of the user when Show is set
+	Gender string `json:"gender"`
+}
+
+// ListGenderIdentities returns the gender identities users can choose from
+func ListGenderIdentities(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	identities, err := getGenderIdentities()
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching gender identities"))
+		return
+	}
+
+	response := struct {
+		Results []models.GenderIdentity `json:"results"`
+	}{
+		Results: identities,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// UserGender serves the gender identity of the user
+// GET returns it and PUT replaces it
+func UserGender(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	switch r.Method {
+	case http.MethodGet:
+		response, err := getUserGender(contextUser)
+		if err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching gender"))
+			return
+		}
+		utils.WriteSuccessResponse(w, http.StatusOK, response)
+	case http.MethodPut:
+		var genderPayload struct {
+			Identity string `json:"identity"`
+			Custom   string `json:"custom"`
+			Show     *bool  `json:"show"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&genderPayload); err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+			return
+		}
+
+		var identity models.GenderIdentity
+		err := core.GetDb().Where("slug = ?", genderPayload.Identity).First(&identity).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Unknown gender identity: %s", genderPayload.Identity)))
+			return
+		}
+		if err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error saving gender"))
+			return
+		}
+
+		// Only the identities letting users describe their gender keep the custom text
+		custom := strings.TrimSpace(genderPayload.Custom)
+		if !identity.AllowsCustom {
+			custom = ""
+		}
+		if utf8.RuneCountInString(custom) > maxGenderCustomLength {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("custom can't be longer than %d characters", maxGenderCustomLength)))
+			return
+		}
+
+		applyGenderIdentity(&contextUser, identity, custom)
+		if genderPayload.Show != nil {
+			contextUser.ShowGender = *genderPayload.Show
+		}
+
+		err = core.GetDb().Model(&models.User{}).Where("id = ?", contextUser.ID).Updates(map[string]interface{}{
+			"gender":             contextUser.Gender,
+			"gender_identity_id": contextUser.GenderIdentityID,
+			"gender_group":       contextUser.GenderGroup,
+			"gender_custom":      contextUser.GenderCustom,
+			"show_gender":        contextUser.ShowGender,
+		}).Error
+		if err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error saving gender"))
+			return
+		}
+
+		response, err := getUserGender(contextUser)
+		if err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching gender"))
+			return
+		}
+		utils.WriteSuccessResponse(w, http.StatusOK, response)
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+	}
+}
+
+func CreateGenderIdentity(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var identity models.GenderIdentity
+	if err := json.NewDecoder(r.Body).Decode(&identity); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+	identity.ID = 0
+	identity.Slug = strings.ToLower(strings.TrimSpace(identity.Slug))
+
+	if err := validateGenderIdentity(identity); err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+	if identity.Slug == "" || len(identity.Slug) > 64 {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid slug"))
+		return
+	}
+
+	var count int64
+	if err := core.GetDb().Model(&models.GenderIdentity{}).Where("slug = ?", identity.Slug).Count(&count).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating gender identity"))
+		return
+	}
+	if count > 0 {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusConflict, fmt.Sprintf("Gender identity already exists: %s", identity.Slug)))
+		return
+	}
+
+	if err := core.GetDb().Create(&identity).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating gender identity"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusCreated, identity)
+}
+
+// UpdateGenderIdentity changes an identity of the list, the users with the identity
+// follow the new label and match group
+func UpdateGenderIdentity(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP PUT Method
+	if r.Method != http.MethodPut {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	identityID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid gender identity ID"))
+		return
+	}
+
+	var identity models.GenderIdentity
+	err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+		err := tx.First(&identity, identityID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NewAppError(http.StatusNotFound, "Gender identity not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		// The slug is the stable identifier of the identity and can't be changed
+		slug := identity.Slug
+		if err := json.NewDecoder(r.Body).Decode(&identity); err != nil {
+			return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err))
+		}
+		identity.ID = identityID
+		identity.Slug = slug
+
+		if err := validateGenderIdentity(identity); err != nil {
+			return err
+		}
+		if err := tx.Save(&identity).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).Where("gender_identity_id = ?", identity.ID).Updates(map[string]interface{}{
+			"gender_group": identity.MatchGroup,
+			"gender":       gorm.Expr("CASE WHEN ? AND gender_custom <> '' THEN gender_custom ELSE ? END", identity.AllowsCustom, identity.Label),
+		}).Error
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error updating gender identity")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, identity)
+}
+
+func validateGenderIdentity(identity models.GenderIdentity) error {
+	if strings.TrimSpace(identity.Label) == "" {
+		return utils.NewAppError(http.StatusBadRequest, "label is required")
+	}
+	if !slices.Contains(models.GenderGroups, identity.MatchGroup) {
+		return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("matchGroup must be one of: %s", strings.Join(models.GenderGroups, ", ")))
+	}
+	return nil
+}
+
+// getGenderIdentities returns the gender identities in the order they are shown to users
+func getGenderIdentities() ([]models.GenderIdentity, error) {
+	identities := []models.GenderIdentity{}
+	err := core.GetDb().Order("position ASC, id ASC").Find(&identities).Error
+	return identities, err
+}
+
+func getUserGender(user models.User) (UserGenderResponse, error) {
+	response := UserGenderResponse{
+		MatchGroup: user.GenderGroup,
+		Custom:     user.GenderCustom,
+		Show:       user.ShowGender,
+		Gender:     user.Gender,
+	}
+
+	if user.GenderIdentityID != nil {
+		var identity models.GenderIdentity
+		if err := core.GetDb().First(&identity, *user.GenderIdentityID).Error; err != nil {
+			return response, err
+		}
+		response.Identity = identity.Slug
+	}
+	return response, nil
+}
+
+// applyGenderIdentity sets the gender identity of the user along with the gender shown on their profile
+func applyGenderIdentity(user *models.User, identity models.GenderIdentity, custom string) {
+	user.GenderIdentityID = &identity.ID
+	user.GenderGroup = identity.MatchGroup
+	user.GenderCustom = custom
+	user.Gender = models.GenderLabel(identity, custom)
+}
+
+// genderGroupsOf maps genders written as match groups or identities to match groups,
+// unknown genders are kept as they are
+func genderGroupsOf(genders []string) ([]string, error) {
+	identities, err := getGenderIdentities()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []string{}
+	for _, gender := range genders {
+		group := strings.ToLower(strings.TrimSpace(gender))
+		if !slices.Contains(models.GenderGroups, group) {
+			slug, custom := models.LegacyGenderSlug(gender, identities)
+			for _, identity := range identities {
+				if identity.Slug == slug && custom == "" {
+					group = identity.MatchGroup
+				}
+			}
+		}
+		if !slices.Contains(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// publicGender returns the gender shown to other users, empty when the user hides it
+func publicGender(user models.User) string {
+	if !user.ShowGender {
+		return ""
+	}
+	return user.Gender
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/entitlements"
+	"dating-app/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errDailyLikeLimitReached = errors.New("daily like limit reached")
+
+type DailyLikeLimitDetails struct {
+	Limit   int       `json:"limit"`
+	ResetAt time.Time `json:"resetAt"`
+	// ExtraLikeCost is the number of credits paying for a like over the limit
+	ExtraLikeCost int `json:"extraLikeCost,omitempty"`
+}
+
+// dailyLikeLimit returns the number of likes the user can send per day, zero means unlimited
+func dailyLikeLimit(user models.User, userEntitlements entitlements.Set) int {
+	if userEntitlements.Has(entitlements.UnlimitedLikes) {
+		return 0
+	}
+	if user.DailyLikeLimit != nil {
+		return *user.DailyLikeLimit
+	}
+	return core.AppConfig.DAILY_LIKE_LIMIT
+}
+
+// likeLimitDay returns the UTC day the like is counted in and when that day ends
+func likeLimitDay(now time.Time) (string, time.Time) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start.Format(time.DateOnly), start.AddDate(0, 0, 1)
+}
+
+// takeDailyLike counts a like sent by the user within the swipe transaction
+// The counter is only incremented while it's below the limit, so concurrent
+// swipes can't go over it, and errDailyLikeLimitReached is returned otherwise
+func takeDailyLike(tx *gorm.DB, user models.User, limit int, now time.Time) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	day, _ := likeLimitDay(now)
+	counter := models.DailyLikeCount{UserID: user.ID, Day: day}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&counter).Error; err != nil {
+		return err
+	}
+
+	result := tx.Model(&models.DailyLikeCount{}).
+		Where("user_id = ? AND day = ? AND likes < ?", user.ID, day, limit).
+		Update("likes", gorm.Expr("likes + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errDailyLikeLimitReached
+	}
+
+	return nil
+}
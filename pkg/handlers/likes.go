@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type ReceivedLikeResponse struct {
+	ID             uint64    `json:"id"`
+	Name           string    `json:"name"`
+	Gender         string    `json:"gender,omitempty"`
+	Age            int       `json:"age"`
+	DistanceFromMe float64   `json:"distanceFromMe"`
+	LikedAt        time.Time `json:"likedAt"`
+}
+
+type ReceivedLikesResponse struct {
+	Count    int64                  `json:"count"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"pageSize"`
+	Results  []ReceivedLikeResponse `json:"results"`
+}
+
+type ReceivedLikesCountResponse struct {
+	Count int64 `json:"count"`
+}
+
+func GetReceivedLikes(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	page, pageSize, err := utils.ParsePagination(r, 20, 100)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	var count int64
+	err = pendingLikesQuery(contextUser.ID).Distinct("swipes.swiper_id").Count(&count).Error
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error counting received likes"))
+		return
+	}
+
+	// Hide the identities of the likers and only return the number of pending likes
+	// unless the plan of the user reveals them
+	if !canSeeLikers(contextUser.ID) {
+		utils.WriteSuccessResponse(w, http.StatusOK, ReceivedLikesCountResponse{Count: count})
+		return
+	}
+
+	var rows []struct {
+		models.User
+		LikedAt time.Time
+	}
+	err = pendingLikesQuery(contextUser.ID).
+		Select("users.id, users.name, users.gender, users.show_gender, users.age, users.latitude, users.longitude, MAX(swipes.created_at) AS liked_at").
+		Joins("JOIN users ON users.id = swipes.swiper_id").
+		Group("users.id").
+		Order("liked_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&rows).Error
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching received likes"))
+		return
+	}
+
+	// Used as a data transfer object to only expose the public profile fields
+	results := make([]ReceivedLikeResponse, len(rows))
+	for i, row := range rows {
+		results[i] = ReceivedLikeResponse{
+			ID:             row.ID,
+			Name:           row.Name,
+			Gender:         publicGender(row.User),
+			Age:            row.Age,
+			DistanceFromMe: utils.CalculateDistance(contextUser.Latitude, contextUser.Longitude, row.Latitude, row.Longitude),
+			LikedAt:        row.LikedAt,
+		}
+	}
+
+	response := ReceivedLikesResponse{
+		Count:    count,
+		Page:     page,
+		PageSize: pageSize,
+		Results:  results,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// pendingLikesQuery selects the YES swipes targeting the user
+// from visible swipers the user has not swiped on yet
+func pendingLikesQuery(userID uint64) *gorm.DB {
+	return core.GetDb().Model(&models.Swipe{}).
+		Where("swipes.target_id = ? AND swipes.swipe_type = ?", userID, "YES").
+		Scopes(visibleTo(userID, "swipes.swiper_id"), notSwipedBy(userID, "swipes.swiper_id"))
+}
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxMessageLength = 2000
+
+type ReadMarkerResponse struct {
+	UserID            uint64 `json:"userID"`
+	LastReadMessageID uint64 `json:"lastReadMessageID"`
+}
+
+type ListMessagesResponse struct {
+	Results     []models.Message     `json:"results"`
+	NextCursor  *uint64              `json:"nextCursor,omitempty"`
+	ReadOnly    bool                 `json:"readOnly"`
+	ReadMarkers []ReadMarkerResponse `json:"readMarkers"`
+}
+
+type ConversationUnreadResponse struct {
+	MatchID uint64 `json:"matchID"`
+	Unread  int64  `json:"unread"`
+}
+
+type UnreadCountsResponse struct {
+	Total         int64                        `json:"total"`
+	Conversations []ConversationUnreadResponse `json:"conversations"`
+}
+
+// MatchMessages serves the conversation of a match
+// GET lists the messages and POST sends a new message
+func MatchMessages(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet:
+		listMessages(w, r)
+	case http.MethodPost:
+		sendMessage(w, r)
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+	}
+}
+
+func sendMessage(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	match, err := getConversationMatch(r, contextUser.ID)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	// Conversations of deleted matches are kept for history but can't receive new messages
+	if match.DeletedAt.Valid {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusForbidden, "Conversation is read-only"))
+		return
+	}
+
+	var messagePayload struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&messagePayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+
+	body := strings.TrimSpace(messagePayload.Body)
+	if body == "" {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Message body is required"))
+		return
+	}
+	if utf8.RuneCountInString(body) > maxMessageLength {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Message body can't be longer than %d characters", maxMessageLength)))
+		return
+	}
+
+	message := models.Message{
+		MatchID:  match.ID,
+		SenderID: contextUser.ID,
+		Body:     body,
+	}
+	if err := core.GetDb().Create(&message).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating message"))
+		return
+	}
+
+	// The sender has obviously read their own message
+	if err := markConversationRead(match.ID, contextUser.ID, message.ID); err != nil {
+		fmt.Printf("Error updating sender read marker: %v\n", err)
+	}
+
+	publishMessageCreated(match, message)
+
+	utils.WriteSuccessResponse(w, http.StatusCreated, message)
+}
+
+func listMessages(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	match, err := getConversationMatch(r, contextUser.ID)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid limit parameter"))
+			return
+		}
+		limit = min(parsed, 100)
+	}
+
+	// Messages are returned newest first, the cursor is the ID of
+	// the oldest message the client already has
+	query := core.GetDb().Where("match_id = ?", match.ID)
+	if value := r.URL.Query().Get("before"); value != "" {
+		before, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid before parameter"))
+			return
+		}
+		query = query.Where("id < ?", before)
+	}
+
+	messages := []models.Message{}
+	if err := query.Order("id DESC").Limit(limit).Find(&messages).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching messages"))
+		return
+	}
+
+	var reads []models.ConversationRead
+	if err := core.GetDb().Where("match_id = ?", match.ID).Find(&reads).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching read markers"))
+		return
+	}
+
+	readMarkers := make([]ReadMarkerResponse, len(reads))
+	for i, read := range reads {
+		readMarkers[i] = ReadMarkerResponse{UserID: read.UserID, LastReadMessageID: read.LastReadMessageID}
+	}
+
+	response := ListMessagesResponse{
+		Results:     messages,
+		ReadOnly:    match.DeletedAt.Valid,
+		ReadMarkers: readMarkers,
+	}
+	if len(messages) == limit {
+		nextCursor := messages[len(messages)-1].ID
+		response.NextCursor = &nextCursor
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+func MarkConversationRead(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	match, err := getConversationMatch(r, contextUser.ID)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	// The message ID is optional, by default the whole conversation is marked as read
+	var readPayload struct {
+		MessageID uint64 `json:"messageID"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&readPayload); err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+			return
+		}
+	}
+
+	var message models.Message
+	query := core.GetDb().Where("match_id = ?", match.ID)
+	if readPayload.MessageID != 0 {
+		query = query.Where("id = ?", readPayload.MessageID)
+	}
+	err = query.Order("id DESC").First(&message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "Message not found"))
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching message"))
+		return
+	}
+
+	if err := markConversationRead(match.ID, contextUser.ID, message.ID); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error updating read marker"))
+		return
+	}
+
+	readMarker := ReadMarkerResponse{UserID: contextUser.ID, LastReadMessageID: message.ID}
+	publishMessageRead(match, readMarker)
+
+	utils.WriteSuccessResponse(w, http.StatusOK, readMarker)
+}
+
+func GetUnreadMessageCounts(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	conversations := []ConversationUnreadResponse{}
+	err := core.GetDb().Table("messages").
+		Select("messages.match_id, COUNT(*) AS unread").
+		Joins("JOIN matches ON matches.id = messages.match_id").
+		Joins("LEFT JOIN conversation_reads ON conversation_reads.match_id = messages.match_id AND conversation_reads.user_id = ?", contextUser.ID).
+		Where("matches.user1_id = ? OR matches.user2_id = ?", contextUser.ID, contextUser.ID).
+		// Leave out the conversations the user lost access to when the other participant blocked them,
+		// like getConversationMatch does
+		Where("NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocked_id = ? AND blocks.blocker_id IN (matches.user1_id, matches.user2_id))", contextUser.ID).
+		Where("messages.sender_id <> ?", contextUser.ID).
+		Where("messages.id > COALESCE(conversation_reads.last_read_message_id, 0)").
+		Group("messages.match_id").
+		Scan(&conversations).Error
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error counting unread messages"))
+		return
+	}
+
+	var total int64
+	for _, conversation := range conversations {
+		total += conversation.Unread
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, UnreadCountsResponse{Total: total, Conversations: conversations})
+}
+
+// getConversationMatch loads the match from the request path, including deleted matches,
+// and makes sure the user is one of its two participants and wasn't blocked by the other one
+func getConversationMatch(r *http.Request, userID uint64) (models.Match, error) {
+	var match models.Match
+
+	matchID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return match, utils.NewAppError(http.StatusBadRequest, "Invalid match ID")
+	}
+
+	err = core.GetDb().Unscoped().First(&match, matchID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return match, utils.NewAppError(http.StatusNotFound, "Match not found")
+	}
+	if err != nil {
+		return match, utils.NewAppError(http.StatusInternalServerError, "Error fetching match")
+	}
+
+	// Returning not found instead of forbidden to avoid leaking other users matches
+	if match.User1ID != userID && match.User2ID != userID {
+		return match, utils.NewAppError(http.StatusNotFound, "Match not found")
+	}
+
+	// Blocked users lose access to the conversation, the blocker keeps it read-only
+	// so they can still report what was said
+	var blocks int64
+	err = core.GetDb().Model(&models.Block{}).Where("blocker_id = ? AND blocked_id = ?", otherMatchUserID(match, userID), userID).Count(&blocks).Error
+	if err != nil {
+		return match, utils.NewAppError(http.StatusInternalServerError, "Error fetching match")
+	}
+	if blocks > 0 {
+		return match, utils.NewAppError(http.StatusNotFound, "Match not found")
+	}
+
+	return match, nil
+}
+
+// markConversationRead moves the read marker of the user forward, it never moves backwards
+func markConversationRead(matchID, userID, messageID uint64) error {
+	read := models.ConversationRead{
+		MatchID:           matchID,
+		UserID:            userID,
+		LastReadMessageID: messageID,
+	}
+	return core.GetDb().Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "match_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_message_id": gorm.Expr("GREATEST(last_read_message_id, VALUES(last_read_message_id))"),
+			"updated_at":           gorm.Expr("VALUES(updated_at)"),
+		}),
+	}).Create(&read).Error
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/realtime"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Default length of a suspension when the moderator doesn't give one
+const defaultSuspensionDays = 7
+
+type ModerationCaseResponse struct {
+	models.ModerationCase
+	ReportCount int64 `json:"reportCount"`
+}
+
+type ModerationCaseDetailsResponse struct {
+	models.ModerationCase
+	Reports []models.Report           `json:"reports"`
+	Actions []models.ModerationAction `json:"actions"`
+}
+
+func ListModerationCases(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	page, pageSize, err := utils.ParsePagination(r, 20, 100)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	query := core.GetDb().Table("moderation_cases")
+	if status := r.URL.Query().Get("status"); status != "" {
+		query = query.Where("moderation_cases.status = ?", status)
+	}
+
+	cases := []ModerationCaseResponse{}
+	err = query.
+		Select("moderation_cases.*, COUNT(reports.id) AS report_count").
+		Joins("LEFT JOIN reports ON reports.case_id = moderation_cases.id").
+		Group("moderation_cases.id").
+		Order("moderation_cases.created_at ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&cases).Error
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching moderation cases"))
+		return
+	}
+
+	response := struct {
+		Results []ModerationCaseResponse `json:"results"`
+	}{
+		Results: cases,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+func GetModerationCase(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	moderationCase, err := getModerationCase(core.GetDb(), r)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	response := ModerationCaseDetailsResponse{ModerationCase: moderationCase}
+	if err := core.GetDb().Where("case_id = ?", moderationCase.ID).Order("id ASC").Find(&response.Reports).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching reports"))
+		return
+	}
+	if err := core.GetDb().Where("case_id = ?", moderationCase.ID).Order("id ASC").Find(&response.Actions).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching moderation actions"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+func ClaimModerationCase(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var moderationCase models.ModerationCase
+	err := core.GetDb().Transaction(func(tx *gorm.DB) error {
+		var err error
+		moderationCase, err = getModerationCase(tx, r)
+		if err != nil {
+			return err
+		}
+
+		if moderationCase.Status != models.CaseStatusOpen {
+			return utils.NewAppError(http.StatusConflict, fmt.Sprintf("Case is already %s", moderationCase.Status))
+		}
+
+		moderationCase.Status = models.CaseStatusClaimed
+		moderationCase.AssigneeID = &contextUser.ID
+		if err := tx.Save(&moderationCase).Error; err != nil {
+			return err
+		}
+
+		return recordModerationAction(tx, &moderationCase.ID, moderationCase.SubjectUserID, contextUser.ID, models.ModerationActionClaim, "")
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error claiming moderation case")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, moderationCase)
+}
+
+func ResolveModerationCase(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var resolvePayload struct {
+		Action      string `json:"action"`
+		Note        string `json:"note"`
+		SuspendDays int    `json:"suspendDays"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&resolvePayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+
+	switch resolvePayload.Action {
+	case models.ModerationActionWarn, models.ModerationActionSuspend, models.ModerationActionBan, models.ModerationActionDismiss:
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Action must be one of: warn, suspend, ban, dismiss"))
+		return
+	}
+	if resolvePayload.SuspendDays < 0 {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "suspendDays can't be negative"))
+		return
+	}
+
+	var moderationCase models.ModerationCase
+	err := core.GetDb().Transaction(func(tx *gorm.DB) error {
+		var err error
+		moderationCase, err = getModerationCase(tx, r)
+		if err != nil {
+			return err
+		}
+
+		// Cases must be claimed first so two moderators don't work on the same case
+		if moderationCase.Status != models.CaseStatusClaimed {
+			return utils.NewAppError(http.StatusConflict, fmt.Sprintf("Case is %s, it must be claimed first", moderationCase.Status))
+		}
+		if moderationCase.AssigneeID == nil || *moderationCase.AssigneeID != contextUser.ID {
+			return utils.NewAppError(http.StatusConflict, "Case is claimed by another moderator")
+		}
+
+		if err := applyModerationAction(tx, moderationCase.SubjectUserID, resolvePayload.Action, resolvePayload.SuspendDays); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		moderationCase.Status = models.CaseStatusResolved
+		moderationCase.Resolution = resolvePayload.Action
+		moderationCase.ResolvedAt = &now
+		if err := tx.Save(&moderationCase).Error; err != nil {
+			return err
+		}
+
+		return recordModerationAction(tx, &moderationCase.ID, moderationCase.SubjectUserID, contextUser.ID, resolvePayload.Action, resolvePayload.Note)
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error resolving moderation case")
+		return
+	}
+
+	if resolvePayload.Action == models.ModerationActionWarn {
+		emitEvent(moderationCase.SubjectUserID, realtime.EventAccountWarning, map[string]string{"note": resolvePayload.Note})
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, moderationCase)
+}
+
+// applyModerationAction updates the account status of the user according to the action
+// Warnings and dismissals leave the account untouched
+// Staff members can't be suspended or banned, like they can't be impersonated, so a report
+// against an admin can't be used by a moderator to lock them out
+func applyModerationAction(tx *gorm.DB, userID uint64, action string, suspendDays int) error {
+	if action == models.ModerationActionSuspend || action == models.ModerationActionBan {
+		var user models.User
+		if err := tx.Select("id", "role").First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.Role != models.RoleUser {
+			return utils.NewAppError(http.StatusForbidden, "Cannot suspend or ban staff members")
+		}
+	}
+
+	updates := map[string]interface{}{}
+
+	switch action {
+	case models.ModerationActionSuspend:
+		if suspendDays == 0 {
+			suspendDays = defaultSuspensionDays
+		}
+		updates["status"] = models.UserStatusSuspended
+		updates["suspended_until"] = time.Now().AddDate(0, 0, suspendDays)
+	case models.ModerationActionBan:
+		updates["status"] = models.UserStatusBanned
+		updates["suspended_until"] = nil
+	default:
+		return nil
+	}
+
+	return tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error
+}
+
+func recordModerationAction(tx *gorm.DB, caseID *uint64, subjectUserID, moderatorID uint64, action string, note string) error {
+	return tx.Create(&models.ModerationAction{
+		CaseID:        caseID,
+		SubjectUserID: subjectUserID,
+		ModeratorID:   moderatorID,
+		Action:        action,
+		Note:          note,
+	}).Error
+}
+
+// getModerationCase loads the case from the request path, locking the row for the transaction
+func getModerationCase(tx *gorm.DB, r *http.Request) (models.ModerationCase, error) {
+	var moderationCase models.ModerationCase
+
+	caseID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return moderationCase, utils.NewAppError(http.StatusBadRequest, "Invalid case ID")
+	}
+
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&moderationCase, caseID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return moderationCase, utils.NewAppError(http.StatusNotFound, "Case not found")
+	}
+	if err != nil {
+		return moderationCase, utils.NewAppError(http.StatusInternalServerError, "Error fetching moderation case")
+	}
+
+	return moderationCase, nil
+}
+
+// writeTransactionError writes the application errors returned by a transaction as they are
+// and replaces the other errors with the given message
+func writeTransactionError(w http.ResponseWriter, err error, message string) {
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		utils.WriteErrorResponse(w, appErr)
+		return
+	}
+	utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, message))
+}
@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/media"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Room left in the request body for the multipart boundaries and headers around the photo
+const multipartOverheadBytes = 64 << 10
+
+type PhotoResponse struct {
+	ID        uint64 `json:"id"`
+	Position  int    `json:"position"`
+	IsPrimary bool   `json:"isPrimary"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	// URLs maps the variant names to signed links expiring after MEDIA_URL_TTL_MINUTES
+	URLs map[string]string `json:"urls"`
+}
+
+// Photos serves the profile photos of the user
+// GET lists the photos and POST uploads a new one
+func Photos(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet:
+		listPhotos(w, r)
+	case http.MethodPost:
+		uploadPhoto(w, r)
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+	}
+}
+
+func uploadPhoto(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	maxBytes := core.AppConfig.PHOTO_MAX_UPLOAD_BYTES
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+multipartOverheadBytes)
+
+	file, _, err := r.FormFile("photo")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Photos can't exceed %d bytes", maxBytes)))
+			return
+		}
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "The photo must be sent in the photo field of a multipart form"))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Error reading photo"))
+		return
+	}
+	if int64(len(data)) > maxBytes {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Photos can't exceed %d bytes", maxBytes)))
+		return
+	}
+
+	processed, err := media.ProcessImage(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusUnsupportedMediaType, err.Error()))
+		return
+	}
+	if errors.Is(err, media.ErrInvalidImage) || errors.Is(err, media.ErrImageTooLarge) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, err.Error()))
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error processing photo"))
+		return
+	}
+
+	// Store the variants first, they are removed again if the photo can't be saved
+	photo := models.Photo{
+		UserID:   contextUser.ID,
+		Width:    processed.Width,
+		Height:   processed.Height,
+		PHash:    processed.Hashes.PHash,
+		DHash:    processed.Hashes.DHash,
+		Variants: map[string]string{},
+	}
+	prefix, err := newPhotoPrefix(contextUser.ID)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error storing photo"))
+		return
+	}
+	for _, variant := range processed.Variants {
+		key := fmt.Sprintf("%s/%s.jpg", prefix, variant.Name)
+		photo.Variants[variant.Name] = key
+		if err := media.GetBlobStore().Put(key, variant.Data); err != nil {
+			deletePhotoBlobs(photo)
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error storing photo"))
+			return
+		}
+	}
+
+	err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+		// Lock the user so concurrent uploads can't go over the limit or share a position
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, contextUser.ID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.Photo{}).Where("user_id = ?", contextUser.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(core.AppConfig.PHOTO_MAX_PER_USER) {
+			return utils.NewAppError(http.StatusConflict, fmt.Sprintf("A profile can't have more than %d photos", core.AppConfig.PHOTO_MAX_PER_USER))
+		}
+
+		// The first photo of the user becomes the primary one
+		photo.Position = int(count)
+		photo.IsPrimary = count == 0
+		if err := tx.Create(&photo).Error; err != nil {
+			return err
+		}
+		return media.IndexPhotoHashes(tx, photo)
+	})
+	if err != nil {
+		deletePhotoBlobs(photo)
+		writeTransactionError(w, err, "Error saving photo")
+		return
+	}
+
+	checkDuplicatePhotos(photo)
+
+	publishProfileUpdated(contextUser.ID)
+
+	utils.WriteSuccessResponse(w, http.StatusCreated, newPhotoResponse(photo, mediaURLSigner(), time.Now()))
+}
+
+func listPhotos(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	photos, err := getPhotos([]uint64{contextUser.ID})
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching photos"))
+		return
+	}
+
+	response := struct {
+		Results []PhotoResponse `json:"results"`
+	}{
+		Results: photos[contextUser.ID],
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// ReorderPhotos replaces the order of the photos of the user
+// Every photo of the user must be listed exactly once
+func ReorderPhotos(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP PUT Method
+	if r.Method != http.MethodPut {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var orderPayload struct {
+		PhotoIDs []uint64 `json:"photoIDs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&orderPayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+
+	err := core.GetDb().Transaction(func(tx *gorm.DB) error {
+		var photos []models.Photo
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", contextUser.ID).Find(&photos).Error; err != nil {
+			return err
+		}
+
+		positions := map[uint64]int{}
+		for position, photoID := range orderPayload.PhotoIDs {
+			if _, exists := positions[photoID]; exists {
+				return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Photo %d is listed more than once", photoID))
+			}
+			positions[photoID] = position
+		}
+		if len(positions) != len(photos) {
+			return utils.NewAppError(http.StatusBadRequest, "photoIDs must list every photo of the user")
+		}
+
+		for _, photo := range photos {
+			position, exists := positions[photo.ID]
+			if !exists {
+				return utils.NewAppError(http.StatusBadRequest, "photoIDs must list every photo of the user")
+			}
+			if err := tx.Model(&photo).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error reordering photos")
+		return
+	}
+
+	publishProfileUpdated(contextUser.ID)
+
+	listPhotos(w, r)
+}
+
+// SetPrimaryPhoto makes the photo the one shown first on the profile of the user
+func SetPrimaryPhoto(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var photo models.Photo
+	err := core.GetDb().Transaction(func(tx *gorm.DB) error {
+		var err error
+		photo, err = getUserPhoto(tx, r, contextUser.ID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Photo{}).
+			Where("user_id = ?", contextUser.ID).
+			Update("is_primary", gorm.Expr("id = ?", photo.ID)).Error
+		photo.IsPrimary = true
+		return err
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error updating primary photo")
+		return
+	}
+
+	publishProfileUpdated(contextUser.ID)
+
+	utils.WriteSuccessResponse(w, http.StatusOK, newPhotoResponse(photo, mediaURLSigner(), time.Now()))
+}
+
+// DeletePhoto removes the photo and its variants, the photos after it move up one position
+// and the first remaining photo becomes the primary one when the primary photo is deleted
+func DeletePhoto(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP DELETE Method
+	if r.Method != http.MethodDelete {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var photo models.Photo
+	err := core.GetDb().Transaction(func(tx *gorm.DB) error {
+		var err error
+		photo, err = getUserPhoto(tx, r, contextUser.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&photo).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("photo_id = ?", photo.ID).Delete(&models.PhotoHashBand{}).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Photo{}).
+			Where("user_id = ? AND position > ?", contextUser.ID, photo.Position).
+			Update("position", gorm.Expr("position - 1")).Error
+		if err != nil {
+			return err
+		}
+
+		if !photo.IsPrimary {
+			return nil
+		}
+		return tx.Model(&models.Photo{}).
+			Where("user_id = ? AND position = 0", contextUser.ID).
+			Update("is_primary", true).Error
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error deleting photo")
+		return
+	}
+
+	deletePhotoBlobs(photo)
+	publishProfileUpdated(contextUser.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServeMedia serves the stored files through the signed links given in the API responses
+// The links work without authentication so the clients can load them like any other image
+func ServeMedia(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	key := r.PathValue("key")
+	query := r.URL.Query()
+	now := time.Now()
+	if !mediaURLSigner().Verify(key, query.Get("expires"), query.Get("signature"), now) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusForbidden, "Invalid or expired link"))
+		return
+	}
+
+	file, err := media.GetBlobStore().Open(key)
+	if errors.Is(err, media.ErrBlobNotFound) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "File not found"))
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching file"))
+		return
+	}
+	defer file.Close()
+
+	// The browsers can keep the file until the link expires
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", expires-now.Unix()))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		fmt.Printf("Error serving file %s: %v\n", key, err)
+	}
+}
+
+// getPhotos loads the photos of the users in order with their signed links
+func getPhotos(userIDs []uint64) (map[uint64][]PhotoResponse, error) {
+	photos := map[uint64][]PhotoResponse{}
+	for _, userID := range userIDs {
+		photos[userID] = []PhotoResponse{}
+	}
+	if len(userIDs) == 0 {
+		return photos, nil
+	}
+
+	var storedPhotos []models.Photo
+	if err := core.GetDb().Where("user_id IN ?", userIDs).Order("user_id ASC, position ASC").Find(&storedPhotos).Error; err != nil {
+		return nil, err
+	}
+
+	signer := mediaURLSigner()
+	now := time.Now()
+	for _, photo := range storedPhotos {
+		photos[photo.UserID] = append(photos[photo.UserID], newPhotoResponse(photo, signer, now))
+	}
+
+	return photos, nil
+}
+
+// mediaURLSigner returns the signer of the links to the profile photos
+func mediaURLSigner() media.URLSigner {
+	return media.URLSigner{
+		BaseURL: core.AppConfig.MEDIA_BASE_URL,
+		Key:     []byte(core.AppConfig.MEDIA_SIGNING_KEY),
+		TTL:     time.Duration(core.AppConfig.MEDIA_URL_TTL_MINUTES) * time.Minute,
+	}
+}
+
+func newPhotoResponse(photo models.Photo, signer media.URLSigner, now time.Time) PhotoResponse {
+	urls := map[string]string{}
+	for name, key := range photo.Variants {
+		urls[name] = signer.SignURL(key, now)
+	}
+
+	return PhotoResponse{
+		ID:        photo.ID,
+		Position:  photo.Position,
+		IsPrimary: photo.IsPrimary,
+		Width:     photo.Width,
+		Height:    photo.Height,
+		URLs:      urls,
+	}
+}
+
+// getUserPhoto loads the photo from the request path, locking the photos of the user for the transaction
+func getUserPhoto(tx *gorm.DB, r *http.Request, userID uint64) (models.Photo, error) {
+	var photo models.Photo
+
+	photoID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return photo, utils.NewAppError(http.StatusBadRequest, "Invalid photo ID")
+	}
+
+	var photos []models.Photo
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).Find(&photos).Error; err != nil {
+		return photo, err
+	}
+	for _, photo := range photos {
+		if photo.ID == photoID {
+			return photo, nil
+		}
+	}
+
+	return photo, utils.NewAppError(http.StatusNotFound, "Photo not found")
+}
+
+// newPhotoPrefix returns an unguessable blob store prefix for the variants of a new photo
+func newPhotoPrefix(userID uint64) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("photos/%d/%s", userID, hex.EncodeToString(random)), nil
+}
+
+// deletePhotoBlobs removes the variants of the photo from the blob store
+// Failures are only logged since the photo is already gone from the profile
+func deletePhotoBlobs(photo models.Photo) {
+	for _, key := range photo.Variants {
+		if err := media.GetBlobStore().Delete(key); err != nil {
+			fmt.Printf("Error deleting file %s: %v\n", key, err)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/entitlements"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Bounds of the age preferences
+const (
+	minPreferenceAge = 18
+	maxPreferenceAge = 120
+)
+
+// DiscoveryPreferences serves the discover preferences of the user
+// GET returns the current preferences and PUT replaces them
+func DiscoveryPreferences(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	switch r.Method {
+	case http.MethodGet:
+		preference, err := getDiscoveryPreference(contextUser.ID)
+		if err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching discovery preferences"))
+			return
+		}
+		utils.WriteSuccessResponse(w, http.StatusOK, preference)
+	case http.MethodPut:
+		preference := models.DefaultDiscoveryPreference(contextUser.ID)
+		if err := json.NewDecoder(r.Body).Decode(&preference); err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+			return
+		}
+		preference.UserID = contextUser.ID
+
+		if err := validateDiscoveryPreference(&preference); err != nil {
+			utils.WriteErrorResponse(w, err)
+			return
+		}
+
+		// Only some plans can look further than the default maximum distance
+		if preference.MaxDistanceKm != nil && *preference.MaxDistanceKm > core.AppConfig.DISCOVER_MAX_DISTANCE_KM {
+			userEntitlements, err := getEntitlements(contextUser.ID)
+			if err != nil {
+				utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error saving discovery preferences"))
+				return
+			}
+			if !userEntitlements.Has(entitlements.ExtendedDistance) {
+				utils.WriteErrorResponse(w, utils.NewAppError(http.StatusForbidden, fmt.Sprintf("maxDistanceKm can't be over %g without a plus or gold plan", core.AppConfig.DISCOVER_MAX_DISTANCE_KM)))
+				return
+			}
+		}
+
+		if err := core.GetDb().Save(&preference).Error; err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error saving discovery preferences"))
+			return
+		}
+		utils.WriteSuccessResponse(w, http.StatusOK, preference)
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+	}
+}
+
+// validateDiscoveryPreference checks the preferences and cleans up the genders
+func validateDiscoveryPreference(preference *models.DiscoveryPreference) error {
+	for _, age := range []*int{preference.MinAge, preference.MaxAge} {
+		if age != nil && (*age < minPreferenceAge || *age > maxPreferenceAge) {
+			return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Ages must be between %d and %d", minPreferenceAge, maxPreferenceAge))
+		}
+	}
+	if preference.MinAge != nil && preference.MaxAge != nil && *preference.MinAge > *preference.MaxAge {
+		return utils.NewAppError(http.StatusBadRequest, "minAge can't be greater than maxAge")
+	}
+	if preference.MaxDistanceKm != nil && *preference.MaxDistanceKm <= 0 {
+		return utils.NewAppError(http.StatusBadRequest, "maxDistanceKm must be positive")
+	}
+
+	// Users are interested in match groups rather than in every single identity
+	genders := []string{}
+	for _, gender := range preference.Genders {
+		if !slices.Contains(models.GenderGroups, gender) {
+			return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Genders must be some of: %s", strings.Join(models.GenderGroups, ", ")))
+		}
+		if !slices.Contains(genders, gender) {
+			genders = append(genders, gender)
+		}
+	}
+	preference.Genders = genders
+
+	if preference.Dealbreakers == nil {
+		preference.Dealbreakers = []string{}
+	}
+	for _, dealbreaker := range preference.Dealbreakers {
+		if !slices.Contains(models.Preferences, dealbreaker) {
+			return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Dealbreakers must be some of: %s", strings.Join(models.Preferences, ", ")))
+		}
+	}
+
+	return nil
+}
+
+// getDiscoveryPreference returns the stored preferences of the user or the defaults
+func getDiscoveryPreference(userID uint64) (models.DiscoveryPreference, error) {
+	var preference models.DiscoveryPreference
+	err := core.GetDb().Where("user_id = ?", userID).First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DefaultDiscoveryPreference(userID), nil
+	}
+	return preference, err
+}
+
+// resolveDiscoveryPreference returns the preferences applied to a discover request,
+// the stored preferences overridden by the query parameters
+// Overridden preferences are always dealbreakers since they were explicitly asked for
+func resolveDiscoveryPreference(r *http.Request, user models.User) (models.DiscoveryPreference, error) {
+	preference, err := getDiscoveryPreference(user.ID)
+	if err != nil {
+		return preference, err
+	}
+
+	query := r.URL.Query()
+	overrides := []struct {
+		param      string
+		preference string
+		apply      func(value string) error
+	}{
+		{"minAge", models.PreferenceAge, func(value string) error {
+			minAge, err := strconv.Atoi(value)
+			preference.MinAge = &minAge
+			return err
+		}},
+		{"maxAge", models.PreferenceAge, func(value string) error {
+			maxAge, err := strconv.Atoi(value)
+			preference.MaxAge = &maxAge
+			return err
+		}},
+		{"gender", models.PreferenceGender, func(value string) (err error) {
+			preference.Genders, err = genderGroupsOf(strings.Split(value, ","))
+			return err
+		}},
+		{"maxDistance", models.PreferenceDistance, func(value string) error {
+			maxDistance, err := strconv.ParseFloat(value, 64)
+			preference.MaxDistanceKm = &maxDistance
+			return err
+		}},
+	}
+	for _, override := range overrides {
+		value := query.Get(override.param)
+		if value == "" {
+			continue
+		}
+		if err := override.apply(value); err != nil {
+			return preference, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Invalid %s parameter", override.param))
+		}
+		if !preference.IsDealbreaker(override.preference) {
+			preference.Dealbreakers = append(preference.Dealbreakers, override.preference)
+		}
+	}
+
+	if err := validateDiscoveryPreference(&preference); err != nil {
+		return preference, err
+	}
+
+	// Preferences saved before a plan ended are capped to the default maximum distance
+	if preference.MaxDistanceKm != nil && *preference.MaxDistanceKm > core.AppConfig.DISCOVER_MAX_DISTANCE_KM {
+		userEntitlements, err := getEntitlements(user.ID)
+		if err != nil {
+			return preference, err
+		}
+		if !userEntitlements.Has(entitlements.ExtendedDistance) {
+			maxDistance := core.AppConfig.DISCOVER_MAX_DISTANCE_KM
+			preference.MaxDistanceKm = &maxDistance
+		}
+	}
+
+	return preference, nil
+}
+
+// dealbreakersFilter is a scope hiding the users outside the dealbreakers of the viewer
+func dealbreakersFilter(preference models.DiscoveryPreference, viewer models.User) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if preference.IsDealbreaker(models.PreferenceAge) {
+			if preference.MinAge != nil {
+				db = db.Where("users.age >= ?", *preference.MinAge)
+			}
+			if preference.MaxAge != nil {
+				db = db.Where("users.age <= ?", *preference.MaxAge)
+			}
+		}
+		if preference.IsDealbreaker(models.PreferenceGender) && len(preference.Genders) > 0 {
+			db = db.Where("users.gender_group IN ?", preference.Genders)
+		}
+		if preference.IsDealbreaker(models.PreferenceDistance) && preference.MaxDistanceKm != nil {
+			db = db.Where(withinDistanceSQL("@maxDistanceKm"), map[string]interface{}{
+				"latitude":      viewer.Latitude,
+				"longitude":     viewer.Longitude,
+				"maxDistanceKm": *preference.MaxDistanceKm,
+			})
+		}
+		return db
+	}
+}
+
+// mutualPreferenceFilter is a scope hiding the users whose dealbreakers the viewer doesn't fit,
+// so discover only shows users who could see the viewer back
+// Users who never saved preferences match everyone
+func mutualPreferenceFilter(viewer models.User) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		ageCondition := `(candidate_preferences.min_age IS NULL OR candidate_preferences.min_age <= @age)
+			AND (candidate_preferences.max_age IS NULL OR candidate_preferences.max_age >= @age)`
+		genderCondition := `COALESCE(JSON_LENGTH(candidate_preferences.genders), 0) = 0
+			OR JSON_CONTAINS(candidate_preferences.genders, JSON_QUOTE(@gender))`
+		distanceCondition := `candidate_preferences.max_distance_km IS NULL
+			OR ` + withinDistanceSQL("candidate_preferences.max_distance_km")
+
+		return db.Joins("LEFT JOIN discovery_preferences AS candidate_preferences ON candidate_preferences.user_id = users.id").
+			Where(`candidate_preferences.user_id IS NULL OR (
+				(NOT `+isDealbreakerSQL(models.PreferenceAge)+` OR (`+ageCondition+`))
+				AND (NOT `+isDealbreakerSQL(models.PreferenceGender)+` OR `+genderCondition+`)
+				AND (NOT `+isDealbreakerSQL(models.PreferenceDistance)+` OR `+distanceCondition+`)
+			)`, map[string]interface{}{
+				"age":       viewer.Age,
+				"gender":    viewer.GenderGroup,
+				"latitude":  viewer.Latitude,
+				"longitude": viewer.Longitude,
+			})
+	}
+}
+
+// isDealbreakerSQL is the condition checking whether the preference is a dealbreaker of the candidate
+func isDealbreakerSQL(preference string) string {
+	return fmt.Sprintf(`COALESCE(JSON_CONTAINS(candidate_preferences.dealbreakers, '"%s"'), 0)`, preference)
+}
+
+// withinDistanceSQL is the condition checking whether the user is within the given distance in kilometers
+// of the viewer, using the named @latitude and @longitude parameters
+// Like utils.CalculateDistance, users without coordinates are considered far away
+func withinDistanceSQL(maxDistanceKm string) string {
+	return `(users.latitude <> 0 AND users.longitude <> 0 AND @latitude <> 0 AND @longitude <> 0
+		AND ST_Distance_Sphere(POINT(users.longitude, users.latitude), POINT(@longitude, @latitude)) <= ` + maxDistanceKm + ` * 1000)`
+}
+
+// matchesPreference reports whether the user at the given distance fits the preference
+func matchesPreference(preference models.DiscoveryPreference, name string, user models.User, distance float64) bool {
+	switch name {
+	case models.PreferenceAge:
+		return (preference.MinAge == nil || user.Age >= *preference.MinAge) && (preference.MaxAge == nil || user.Age <= *preference.MaxAge)
+	case models.PreferenceGender:
+		return len(preference.Genders) == 0 || slices.Contains(preference.Genders, user.GenderGroup)
+	case models.PreferenceDistance:
+		return preference.MaxDistanceKm == nil || distance <= *preference.MaxDistanceKm
+	default:
+		return true
+	}
+}
@@ -0,0 +1,460 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Profile limits
+const (
+	maxBioLength          = 500
+	maxProfilePrompts     = 3
+	maxPromptAnswerLength = 250
+	maxProfileInterests   = 10
+)
+
+type ProfilePromptResponse struct {
+	PromptID uint64 `json:"promptID"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// AdminInterestResponse is an interest along with its compatibility weight, which is only shown to admins
+type AdminInterestResponse struct {
+	models.Interest
+	Weight *float64 `json:"weight"`
+}
+
+type ProfileResponse struct {
+	Bio       string                  `json:"bio"`
+	Prompts   []ProfilePromptResponse `json:"prompts"`
+	Interests []models.Interest       `json:"interests"`
+}
+
+// ListPrompts returns the prompts users can answer
+func ListPrompts(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	prompts := []models.Prompt{}
+	if err := core.GetDb().Where("active = ?", true).Order("position ASC, id ASC").Find(&prompts).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching prompts"))
+		return
+	}
+
+	response := struct {
+		Results []models.Prompt `json:"results"`
+	}{
+		Results: prompts,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// ListInterests returns the interests taxonomy
+func ListInterests(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	interests := []models.Interest{}
+	if err := core.GetDb().Order("category ASC, label ASC").Find(&interests).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching interests"))
+		return
+	}
+
+	response := struct {
+		Results []models.Interest `json:"results"`
+	}{
+		Results: interests,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// Profile serves the bio, prompt answers and interests of the user
+// GET returns them and PUT replaces the fields sent, the other fields are left as they are
+func Profile(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	switch r.Method {
+	case http.MethodGet:
+		// The profile is written below for both methods
+	case http.MethodPut:
+		var profilePayload struct {
+			Bio     *string `json:"bio"`
+			Prompts *[]struct {
+				PromptID uint64 `json:"promptID"`
+				Answer   string `json:"answer"`
+			} `json:"prompts"`
+			Interests *[]string `json:"interests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&profilePayload); err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+			return
+		}
+
+		err := core.GetDb().Transaction(func(tx *gorm.DB) error {
+			if profilePayload.Bio != nil {
+				bio := strings.TrimSpace(*profilePayload.Bio)
+				if utf8.RuneCountInString(bio) > maxBioLength {
+					return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("bio can't be longer than %d characters", maxBioLength))
+				}
+				if err := tx.Model(&models.User{}).Where("id = ?", contextUser.ID).Update("bio", bio).Error; err != nil {
+					return err
+				}
+				contextUser.Bio = bio
+			}
+
+			if profilePayload.Prompts != nil {
+				answers := []models.PromptAnswer{}
+				for position, prompt := range *profilePayload.Prompts {
+					answers = append(answers, models.PromptAnswer{
+						UserID:   contextUser.ID,
+						PromptID: prompt.PromptID,
+						Answer:   strings.TrimSpace(prompt.Answer),
+						Position: position,
+					})
+				}
+				if err := replacePromptAnswers(tx, contextUser.ID, answers); err != nil {
+					return err
+				}
+			}
+
+			if profilePayload.Interests != nil {
+				if err := replaceUserInterests(tx, contextUser.ID, *profilePayload.Interests); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			writeTransactionError(w, err, "Error saving profile")
+			return
+		}
+
+		publishProfileUpdated(contextUser.ID)
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	profiles, err := getProfiles([]models.User{contextUser})
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching profile"))
+		return
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, profiles[contextUser.ID])
+}
+
+// replacePromptAnswers validates the answers against the catalog and replaces the answers of the user
+func replacePromptAnswers(tx *gorm.DB, userID uint64, answers []models.PromptAnswer) error {
+	if len(answers) > maxProfilePrompts {
+		return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("A profile can't answer more than %d prompts", maxProfilePrompts))
+	}
+
+	promptIDs := []uint64{}
+	for _, answer := range answers {
+		if slices.Contains(promptIDs, answer.PromptID) {
+			return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Prompt %d is answered more than once", answer.PromptID))
+		}
+		if answer.Answer == "" {
+			return utils.NewAppError(http.StatusBadRequest, "answer is required")
+		}
+		if utf8.RuneCountInString(answer.Answer) > maxPromptAnswerLength {
+			return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("answer can't be longer than %d characters", maxPromptAnswerLength))
+		}
+		promptIDs = append(promptIDs, answer.PromptID)
+	}
+
+	if len(promptIDs) > 0 {
+		var count int64
+		if err := tx.Model(&models.Prompt{}).Where("id IN ? AND active = ?", promptIDs, true).Count(&count).Error; err != nil {
+			return err
+		}
+		if count != int64(len(promptIDs)) {
+			return utils.NewAppError(http.StatusBadRequest, "Unknown or inactive prompt")
+		}
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.PromptAnswer{}).Error; err != nil {
+		return err
+	}
+	if len(answers) == 0 {
+		return nil
+	}
+	return tx.Create(&answers).Error
+}
+
+// replaceUserInterests replaces the interests of the user with the interests of the taxonomy matching the slugs
+func replaceUserInterests(tx *gorm.DB, userID uint64, slugs []string) error {
+	normalized := []string{}
+	for _, slug := range slugs {
+		slug = strings.ToLower(strings.TrimSpace(slug))
+		if !slices.Contains(normalized, slug) {
+			normalized = append(normalized, slug)
+		}
+	}
+	if len(normalized) > maxProfileInterests {
+		return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("A profile can't have more than %d interests", maxProfileInterests))
+	}
+
+	interests := []models.Interest{}
+	if len(normalized) > 0 {
+		if err := tx.Where("slug IN ?", normalized).Find(&interests).Error; err != nil {
+			return err
+		}
+	}
+	known := []string{}
+	for _, interest := range interests {
+		known = append(known, interest.Slug)
+	}
+	for _, slug := range normalized {
+		if !slices.Contains(known, slug) {
+			return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Unknown interest: %s", slug))
+		}
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserInterest{}).Error; err != nil {
+		return err
+	}
+	if len(interests) == 0 {
+		return nil
+	}
+
+	rows := make([]models.UserInterest, len(interests))
+	for i, interest := range interests {
+		rows[i] = models.UserInterest{UserID: userID, InterestID: interest.ID}
+	}
+	return tx.Create(&rows).Error
+}
+
+// getProfiles loads the public profile of the users, the answers to inactive prompts are left out
+func getProfiles(users []models.User) (map[uint64]ProfileResponse, error) {
+	profiles := map[uint64]ProfileResponse{}
+	userIDs := make([]uint64, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+		profiles[user.ID] = ProfileResponse{
+			Bio:       user.Bio,
+			Prompts:   []ProfilePromptResponse{},
+			Interests: []models.Interest{},
+		}
+	}
+	if len(userIDs) == 0 {
+		return profiles, nil
+	}
+
+	var answers []struct {
+		UserID uint64
+		ProfilePromptResponse
+	}
+	err := core.GetDb().Table("prompt_answers").
+		Select("prompt_answers.user_id, prompt_answers.prompt_id, prompts.question, prompt_answers.answer").
+		Joins("JOIN prompts ON prompts.id = prompt_answers.prompt_id AND prompts.active = ?", true).
+		Where("prompt_answers.user_id IN ?", userIDs).
+		Order("prompt_answers.user_id ASC, prompt_answers.position ASC").
+		Scan(&answers).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, answer := range answers {
+		profile := profiles[answer.UserID]
+		profile.Prompts = append(profile.Prompts, answer.ProfilePromptResponse)
+		profiles[answer.UserID] = profile
+	}
+
+	var interests []struct {
+		UserID uint64
+		models.Interest
+	}
+	err = core.GetDb().Table("user_interests").
+		Select("user_interests.user_id, interests.*").
+		Joins("JOIN interests ON interests.id = user_interests.interest_id").
+		Where("user_interests.user_id IN ?", userIDs).
+		Order("user_interests.user_id ASC, interests.category ASC, interests.label ASC").
+		Scan(&interests).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, interest := range interests {
+		profile := profiles[interest.UserID]
+		profile.Interests = append(profile.Interests, interest.Interest)
+		profiles[interest.UserID] = profile
+	}
+
+	return profiles, nil
+}
+
+func CreatePrompt(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	prompt := models.Prompt{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&prompt); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+	prompt.ID = 0
+	prompt.Slug = strings.ToLower(strings.TrimSpace(prompt.Slug))
+	prompt.Question = strings.TrimSpace(prompt.Question)
+
+	if prompt.Question == "" {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "question is required"))
+		return
+	}
+	if prompt.Slug == "" || len(prompt.Slug) > 64 {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid slug"))
+		return
+	}
+
+	var count int64
+	if err := core.GetDb().Model(&models.Prompt{}).Where("slug = ?", prompt.Slug).Count(&count).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating prompt"))
+		return
+	}
+	if count > 0 {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusConflict, fmt.Sprintf("Prompt already exists: %s", prompt.Slug)))
+		return
+	}
+
+	if err := core.GetDb().Create(&prompt).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating prompt"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusCreated, prompt)
+}
+
+// UpdatePrompt changes a prompt of the catalog, deactivating a prompt hides its answers
+func UpdatePrompt(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP PUT Method
+	if r.Method != http.MethodPut {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	promptID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid prompt ID"))
+		return
+	}
+
+	var prompt models.Prompt
+	err = core.GetDb().First(&prompt, promptID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "Prompt not found"))
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching prompt"))
+		return
+	}
+
+	// The slug is the stable identifier of the prompt and can't be changed
+	slug := prompt.Slug
+	if err := json.NewDecoder(r.Body).Decode(&prompt); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+	prompt.ID = promptID
+	prompt.Slug = slug
+	prompt.Question = strings.TrimSpace(prompt.Question)
+
+	if prompt.Question == "" {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "question is required"))
+		return
+	}
+
+	if err := core.GetDb().Save(&prompt).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error updating prompt"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, prompt)
+}
+
+// UpdateInterest changes the label, category or weight of an interest of the taxonomy
+// A null weight goes back to the configured weighting
+func UpdateInterest(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP PUT Method
+	if r.Method != http.MethodPut {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	interestID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid interest ID"))
+		return
+	}
+
+	var interest models.Interest
+	err = core.GetDb().First(&interest, interestID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "Interest not found"))
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching interest"))
+		return
+	}
+
+	// The slug is the stable identifier of the interest and can't be changed
+	var interestPayload struct {
+		Label    string   `json:"label"`
+		Category string   `json:"category"`
+		Weight   *float64 `json:"weight"`
+	}
+	interestPayload.Label = interest.Label
+	interestPayload.Category = interest.Category
+	if err := json.NewDecoder(r.Body).Decode(&interestPayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+	interest.Label = strings.TrimSpace(interestPayload.Label)
+	interest.Category = strings.ToLower(strings.TrimSpace(interestPayload.Category))
+	interest.Weight = interestPayload.Weight
+
+	if interest.Label == "" || interest.Category == "" {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "label and category are required"))
+		return
+	}
+	if interest.Weight != nil && *interest.Weight < 0 {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "weight can't be negative"))
+		return
+	}
+
+	if err := core.GetDb().Save(&interest).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error updating interest"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, AdminInterestResponse{Interest: interest, Weight: interest.Weight})
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/matching"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Questions have between 2 and maxQuestionOptions options
+const maxQuestionOptions = 6
+
+// Number of cached match percentages written per query, every row takes 7 placeholders
+// and MySQL accepts at most 65,535 of them in a statement
+const questionnaireMatchBatchSize = 1000
+
+// ListQuestions returns the questions of the compatibility questionnaire
+func ListQuestions(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	questions := []models.Question{}
+	if err := core.GetDb().Where("active = ?", true).Order("position ASC, id ASC").Find(&questions).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching questions"))
+		return
+	}
+
+	response := struct {
+		Results []models.Question `json:"results"`
+	}{
+		Results: questions,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// ListAnswers returns the questionnaire answers of the user
+func ListAnswers(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	answers := []models.QuestionAnswer{}
+	if err := core.GetDb().Where("user_id = ?", contextUser.ID).Order("question_id ASC").Find(&answers).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching answers"))
+		return
+	}
+
+	response := struct {
+		Results []models.QuestionAnswer `json:"results"`
+	}{
+		Results: answers,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// Answer serves the answer of the user to a question
+// PUT answers the question or replaces the answer and DELETE removes the answer
+// Both invalidate the cached match percentages of the user
+func Answer(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	questionID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid question ID"))
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		err := core.GetDb().Transaction(func(tx *gorm.DB) error {
+			result := tx.Where("user_id = ? AND question_id = ?", contextUser.ID, questionID).Delete(&models.QuestionAnswer{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return utils.NewAppError(http.StatusNotFound, "Answer not found")
+			}
+			return bumpQuestionnaireVersions(tx, []uint64{contextUser.ID})
+		})
+		if err != nil {
+			writeTransactionError(w, err, "Error deleting answer")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var answer models.QuestionAnswer
+	if err := json.NewDecoder(r.Body).Decode(&answer); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+	answer.UserID = contextUser.ID
+	answer.QuestionID = questionID
+
+	err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+		var question models.Question
+		err := tx.Where("id = ? AND active = ?", questionID, true).First(&question).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NewAppError(http.StatusNotFound, "Question not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := validateQuestionAnswer(question, &answer); err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"answer", "acceptable", "importance", "updated_at"}),
+		}).Create(&answer).Error
+		if err != nil {
+			return err
+		}
+		return bumpQuestionnaireVersions(tx, []uint64{contextUser.ID})
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error saving answer")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, answer)
+}
+
+// validateQuestionAnswer checks the answer against the options of the question
+// Irrelevant questions accept every answer when the user doesn't list the acceptable ones
+func validateQuestionAnswer(question models.Question, answer *models.QuestionAnswer) error {
+	if answer.Answer < 0 || answer.Answer >= len(question.Options) {
+		return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("answer must be the index of an option, between 0 and %d", len(question.Options)-1))
+	}
+	if !slices.Contains(models.Importances, answer.Importance) {
+		return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("importance must be one of: %s", strings.Join(models.Importances, ", ")))
+	}
+
+	if len(answer.Acceptable) == 0 && answer.Importance == models.ImportanceIrrelevant {
+		for option := range question.Options {
+			answer.Acceptable = append(answer.Acceptable, option)
+		}
+	}
+	if len(answer.Acceptable) == 0 {
+		return utils.NewAppError(http.StatusBadRequest, "acceptable must list at least one option")
+	}
+
+	acceptable := []int{}
+	for _, option := range answer.Acceptable {
+		if option < 0 || option >= len(question.Options) {
+			return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("acceptable must list indexes of options, between 0 and %d", len(question.Options)-1))
+		}
+		if !slices.Contains(acceptable, option) {
+			acceptable = append(acceptable, option)
+		}
+	}
+	slices.Sort(acceptable)
+	answer.Acceptable = acceptable
+
+	return nil
+}
+
+// bumpQuestionnaireVersions marks the cached match percentages of the users as stale
+func bumpQuestionnaireVersions(tx *gorm.DB, userIDs []uint64) error {
+	return tx.Model(&models.User{}).Where("id IN ?", userIDs).
+		Update("questionnaire_version", gorm.Expr("questionnaire_version + 1")).Error
+}
+
+// getMatchPercentages returns the match percentage of the viewer with every user,
+// nil for the users without any question answered by both
+// The percentages are cached per pair of users until either user changes their answers
+func getMatchPercentages(viewer models.User, users []models.User) (map[uint64]*int, error) {
+	percentages := map[uint64]*int{}
+	if len(users) == 0 {
+		return percentages, nil
+	}
+
+	userIDs := make([]uint64, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+
+	var cached []models.QuestionnaireMatch
+	err := core.GetDb().
+		Where("(user1_id = ? AND user2_id IN ?) OR (user2_id = ? AND user1_id IN ?)", viewer.ID, userIDs, viewer.ID, userIDs).
+		Find(&cached).Error
+	if err != nil {
+		return nil, err
+	}
+	// Key the cached matches by the other user of the pair
+	cachedByUser := map[uint64]models.QuestionnaireMatch{}
+	for _, match := range cached {
+		cachedByUser[match.User1ID+match.User2ID-viewer.ID] = match
+	}
+
+	// Compute the missing and stale percentages
+	// The versions are read before the answers, a percentage computed from newer answers
+	// is only recomputed once more
+	matches := []models.QuestionnaireMatch{}
+	stale := []models.User{}
+	for _, user := range users {
+		match, ok := cachedByUser[user.ID]
+		current := newQuestionnaireMatch(viewer, user, matching.QuestionnaireMatch{}, time.Time{})
+		if ok && match.User1Version == current.User1Version && match.User2Version == current.User2Version {
+			matches = append(matches, match)
+			continue
+		}
+		stale = append(stale, user)
+	}
+
+	if len(stale) > 0 {
+		staleIDs := []uint64{viewer.ID}
+		for _, user := range stale {
+			staleIDs = append(staleIDs, user.ID)
+		}
+		answers, err := getQuestionnaireAnswers(staleIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		// Pairs where a user has no active answers have no percentage, they aren't cached
+		// so the cache only grows with the users taking part in the questionnaire
+		now := time.Now()
+		computed := []models.QuestionnaireMatch{}
+		for _, user := range stale {
+			if len(answers[viewer.ID]) == 0 || len(answers[user.ID]) == 0 {
+				continue
+			}
+			match := matching.MatchPercentage(answers[viewer.ID], answers[user.ID])
+			computed = append(computed, newQuestionnaireMatch(viewer, user, match, now))
+		}
+
+		if len(computed) > 0 {
+			err = core.GetDb().Clauses(clause.OnConflict{
+				DoUpdates: clause.AssignmentColumns([]string{"user1_version", "user2_version", "percentage", "common_questions", "updated_at"}),
+			}).CreateInBatches(&computed, questionnaireMatchBatchSize).Error
+			if err != nil {
+				return nil, err
+			}
+		}
+		matches = append(matches, computed...)
+	}
+
+	for _, match := range matches {
+		if match.CommonQuestions == 0 {
+			continue
+		}
+		percentage := match.Percentage
+		percentages[match.User1ID+match.User2ID-viewer.ID] = &percentage
+	}
+
+	return percentages, nil
+}
+
+// newQuestionnaireMatch returns the cache row of the match percentage of the users, ordered by ID
+func newQuestionnaireMatch(a, b models.User, match matching.QuestionnaireMatch, updatedAt time.Time) models.QuestionnaireMatch {
+	if a.ID > b.ID {
+		a, b = b, a
+	}
+	return models.QuestionnaireMatch{
+		User1ID:         a.ID,
+		User2ID:         b.ID,
+		User1Version:    a.QuestionnaireVersion,
+		User2Version:    b.QuestionnaireVersion,
+		Percentage:      match.Percentage,
+		CommonQuestions: match.CommonQuestions,
+		UpdatedAt:       updatedAt,
+	}
+}
+
+// getQuestionnaireAnswers loads the answers of the users to the active questions
+func getQuestionnaireAnswers(userIDs []uint64) (map[uint64][]models.QuestionAnswer, error) {
+	var answers []models.QuestionAnswer
+	err := core.GetDb().
+		Joins("JOIN questions ON questions.id = question_answers.question_id AND questions.active = ?", true).
+		Where("question_answers.user_id IN ?", userIDs).
+		Find(&answers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := map[uint64][]models.QuestionAnswer{}
+	for _, answer := range answers {
+		byUser[answer.UserID] = append(byUser[answer.UserID], answer)
+	}
+	return byUser, nil
+}
+
+func CreateQuestion(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	question := models.Question{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&question); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+	question.ID = 0
+	question.Text = strings.TrimSpace(question.Text)
+
+	if question.Text == "" {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "text is required"))
+		return
+	}
+	if len(question.Options) < 2 || len(question.Options) > maxQuestionOptions {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("A question must have between 2 and %d options", maxQuestionOptions)))
+		return
+	}
+	for i, option := range question.Options {
+		question.Options[i] = strings.TrimSpace(option)
+		if question.Options[i] == "" {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Options can't be empty"))
+			return
+		}
+	}
+
+	if err := core.GetDb().Create(&question).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating question"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusCreated, question)
+}
+
+// UpdateQuestion changes the text, position or status of a question, its options can't be changed
+// Deactivating or reactivating a question changes the match percentages of the users who answered it
+func UpdateQuestion(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP PUT Method
+	if r.Method != http.MethodPut {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	questionID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid question ID"))
+		return
+	}
+
+	var question models.Question
+	err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+		err := tx.First(&question, questionID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NewAppError(http.StatusNotFound, "Question not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		options := question.Options
+		active := question.Active
+		if err := json.NewDecoder(r.Body).Decode(&question); err != nil {
+			return utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err))
+		}
+		question.ID = questionID
+		question.Options = options
+		question.Text = strings.TrimSpace(question.Text)
+
+		if question.Text == "" {
+			return utils.NewAppError(http.StatusBadRequest, "text is required")
+		}
+		if err := tx.Save(&question).Error; err != nil {
+			return err
+		}
+
+		if question.Active == active {
+			return nil
+		}
+		var userIDs []uint64
+		if err := tx.Model(&models.QuestionAnswer{}).Where("question_id = ?", questionID).Pluck("user_id", &userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+		return bumpQuestionnaireVersions(tx, userIDs)
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error updating question")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, question)
+}
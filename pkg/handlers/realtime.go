@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/realtime"
+	"dating-app/pkg/utils"
+)
+
+func RealtimeSocket(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The StreamAuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP GET Method, WebSocket handshakes are always GET requests
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	// Admins impersonating the user can only watch, the frames they send are not handled
+	handleFrame := realtime.FrameHandler(handleRealtimeFrame)
+	if _, impersonating := r.Context().Value(core.ImpersonationContextKey).(models.Impersonation); impersonating {
+		handleFrame = nil
+	}
+
+	// The upgrader writes the handshake error response itself
+	if err := realtime.ServeWebSocket(w, r, contextUser.ID, handleFrame); err != nil {
+		fmt.Printf("WebSocket connection of user %d closed: %v\n", contextUser.ID, err)
+	}
+}
+
+func EventStream(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The StreamAuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	// Browsers send the Last-Event-ID header when reconnecting on their own,
+	// the query parameter allows clients to resume a fresh connection
+	lastEventIDValue := r.Header.Get("Last-Event-ID")
+	if lastEventIDValue == "" {
+		lastEventIDValue = r.URL.Query().Get("lastEventId")
+	}
+
+	var lastEventID uint64
+	if lastEventIDValue != "" {
+		parsed, err := strconv.ParseUint(lastEventIDValue, 10, 64)
+		if err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid Last-Event-ID"))
+			return
+		}
+		lastEventID = parsed
+	}
+
+	if err := realtime.ServeEventStream(w, r, contextUser.ID, lastEventID, replayUserEvents); err != nil {
+		fmt.Printf("Event stream of user %d closed: %v\n", contextUser.ID, err)
+	}
+}
+
+// handleRealtimeFrame handles the frames sent by the clients over the WebSocket
+// Chat frames are expected to be added here as the protocol grows
+func handleRealtimeFrame(userID uint64, frame realtime.Frame) error {
+
+	switch frame.Type {
+	case realtime.FrameTyping:
+		var typingPayload struct {
+			MatchID uint64 `json:"matchID"`
+		}
+		if err := json.Unmarshal(frame.Data, &typingPayload); err != nil {
+			return errors.New("Invalid typing frame")
+		}
+
+		var match models.Match
+		err := core.GetDb().Where("id = ? AND (user1_id = ? OR user2_id = ?)", typingPayload.MatchID, userID, userID).First(&match).Error
+		if err != nil {
+			return errors.New("Match not found")
+		}
+
+		publishTyping(match, userID)
+		return nil
+	default:
+		return fmt.Errorf("Unsupported frame type: %s", frame.Type)
+	}
+}
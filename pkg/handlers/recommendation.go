@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/recommend"
+
+	"gorm.io/gorm"
+)
+
+// getRecommendationScores predicts with the latest recommendation model how likely the viewer
+// and each user are to like each other, see recommend.Reciprocal
+// Empty until the train-recommendations job stored a model
+func getRecommendationScores(viewerID uint64, userIDs []uint64) (map[uint64]float64, error) {
+	scores := map[uint64]float64{}
+	if len(userIDs) == 0 {
+		return scores, nil
+	}
+
+	var model models.RecommendationModel
+	err := core.GetDb().Order("id DESC").First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return scores, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.UserEmbedding
+	err = core.GetDb().Where("model_id = ? AND user_id IN ?", model.ID, append([]uint64{viewerID}, userIDs...)).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	embeddings := map[uint64]*recommend.Embedding{}
+	for _, row := range rows {
+		embeddings[row.UserID] = &recommend.Embedding{
+			Taste:      row.Taste,
+			Appeal:     row.Appeal,
+			TasteBias:  row.TasteBias,
+			AppealBias: row.AppealBias,
+		}
+	}
+
+	// Users who joined after the training get the average behaviour of the model
+	viewer := embeddings[viewerID]
+	for _, userID := range userIDs {
+		viewerLikes := recommend.LikeProbability(model.GlobalBias, viewer, embeddings[userID])
+		userLikes := recommend.LikeProbability(model.GlobalBias, embeddings[userID], viewer)
+		scores[userID] = recommend.Reciprocal(viewerLikes, userLikes)
+	}
+	return scores, nil
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxReportDetailsLength = 1000
+
+type CreateReportResponse struct {
+	ID     uint64 `json:"id"`
+	Reason string `json:"reason"`
+}
+
+func CreateReport(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var reportPayload struct {
+		UserID  uint64 `json:"userID"`
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reportPayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+
+	if reportPayload.UserID == contextUser.ID {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Cannot report yourself"))
+		return
+	}
+	if !slices.Contains(models.ReportReasons, reportPayload.Reason) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Reason must be one of: %s", strings.Join(models.ReportReasons, ", "))))
+		return
+	}
+	details := strings.TrimSpace(reportPayload.Details)
+	if utf8.RuneCountInString(details) > maxReportDetailsLength {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Details can't be longer than %d characters", maxReportDetailsLength)))
+		return
+	}
+
+	var reportedUser models.User
+	if err := core.GetDb().First(&reportedUser, reportPayload.UserID).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "User not found"))
+		return
+	}
+
+	report := models.Report{
+		ReporterID:     contextUser.ID,
+		ReportedUserID: reportedUser.ID,
+		Reason:         reportPayload.Reason,
+		Details:        details,
+	}
+	err := core.GetDb().Transaction(func(tx *gorm.DB) error {
+		// Reports about a user are grouped in their unresolved case if there is one
+		// The reported user row is locked so concurrent reports can't open two cases
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, reportedUser.ID).Error; err != nil {
+			return err
+		}
+
+		var moderationCase models.ModerationCase
+		err := tx.Where("subject_user_id = ? AND status <> ?", reportedUser.ID, models.CaseStatusResolved).First(&moderationCase).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			moderationCase = models.ModerationCase{
+				SubjectUserID: reportedUser.ID,
+				Status:        models.CaseStatusOpen,
+			}
+			err = tx.Create(&moderationCase).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		report.CaseID = moderationCase.ID
+		return tx.Create(&report).Error
+	})
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating report"))
+		return
+	}
+
+	// The moderation case is internal and not returned to the reporter
+	utils.WriteSuccessResponse(w, http.StatusCreated, CreateReportResponse{ID: report.ID, Reason: report.Reason})
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dating-app/pkg/antispam"
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Maximum number of recent swipes loaded to evaluate the behaviour of a user
+const maxEvaluatedSwipes = 1000
+
+// recordDiscoverFetch logs a discover request for the spam detector
+func recordDiscoverFetch(userID uint64) {
+	if err := core.GetDb().Create(&models.DiscoverFetch{UserID: userID}).Error; err != nil {
+		fmt.Printf("Error recording discover fetch: %v\n", err)
+	}
+}
+
+var spamCheckThrottle = antispam.NewThrottle()
+
+// checkSwipeBehaviour runs the spam detector against the recent activity of the user
+// in the background and flags the account for every rule it triggers
+// The activity of a user is evaluated at most once per SPAM_CHECK_INTERVAL_SECONDS,
+// the swipes sent in between are evaluated at the end of the interval
+func checkSwipeBehaviour(userID uint64) {
+	interval := time.Duration(core.AppConfig.SPAM_CHECK_INTERVAL_SECONDS) * time.Second
+	allowed, delay := spamCheckThrottle.Allow(userID, time.Now(), interval)
+	if allowed {
+		go evaluateSwipeBehaviour(userID)
+	} else if delay > 0 {
+		time.AfterFunc(delay, func() { evaluateSwipeBehaviour(userID) })
+	}
+}
+
+func evaluateSwipeBehaviour(userID uint64) {
+	detector := antispam.NewDetector(antispam.ConfiguredThresholds())
+
+	activity, err := antispam.LoadActivity(core.GetDb(), userID, time.Now().Add(-detector.Lookback), maxEvaluatedSwipes)
+	if err != nil {
+		fmt.Printf("Error loading swipe activity: %v\n", err)
+		return
+	}
+
+	for _, verdict := range detector.Evaluate(activity) {
+		if err := antispam.RecordFlag(core.GetDb(), verdict); err != nil {
+			fmt.Printf("Error recording spam flag: %v\n", err)
+		}
+	}
+}
+
+func ListSpamFlags(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	page, pageSize, err := utils.ParsePagination(r, 20, 100)
+	if err != nil {
+		utils.WriteErrorResponse(w, err)
+		return
+	}
+
+	query := core.GetDb().Model(&models.SpamFlag{})
+	if status := r.URL.Query().Get("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if rule := r.URL.Query().Get("rule"); rule != "" {
+		query = query.Where("rule = ?", rule)
+	}
+
+	flags := []models.SpamFlag{}
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&flags).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching spam flags"))
+		return
+	}
+
+	response := struct {
+		Results []models.SpamFlag `json:"results"`
+	}{
+		Results: flags,
+	}
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+func ReviewSpamFlag(w http.ResponseWriter, r *http.Request) {
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	flagID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid flag ID"))
+		return
+	}
+
+	var flag models.SpamFlag
+	err = core.GetDb().First(&flag, flagID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusNotFound, "Spam flag not found"))
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching spam flag"))
+		return
+	}
+
+	// Sanctions are applied through the admin user endpoints, reviewing only closes the flag
+	flag.Status = models.SpamFlagStatusReviewed
+	if err := core.GetDb().Save(&flag).Error; err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error updating spam flag"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, flag)
+}
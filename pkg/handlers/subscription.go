@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/entitlements"
+	"dating-app/pkg/models"
+	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type SubscriptionResponse struct {
+	entitlements.Set
+	Subscription *models.Subscription `json:"subscription"`
+}
+
+func GetSubscription(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP GET Method
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	response, err := getSubscriptionResponse(contextUser.ID)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching subscription"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// PurchaseSubscription redeems an app store receipt for the plan it was bought for
+// Redeeming the same receipt again is a no-op, so clients can safely retry
+func PurchaseSubscription(w http.ResponseWriter, r *http.Request) {
+
+	// Retrieve user from context
+	// The AuthMiddleware is handling errors related to not finding the user
+	contextUser, _ := r.Context().Value(core.UserContextKey).(models.User)
+
+	// Only allow HTTP POST Method
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusMethodNotAllowed, fmt.Sprintf("Method not allowed: %s", r.Method)))
+		return
+	}
+
+	var purchasePayload struct {
+		Platform string `json:"platform"`
+		Receipt  string `json:"receipt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&purchasePayload); err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, fmt.Sprintf("Error decoding request body: %v", err)))
+		return
+	}
+
+	var source string
+	switch strings.ToLower(purchasePayload.Platform) {
+	case models.PlatformIOS:
+		source = models.SubscriptionSourceAppStore
+	case models.PlatformAndroid:
+		source = models.SubscriptionSourcePlayStore
+	default:
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Platform must be ios or android"))
+		return
+	}
+
+	purchase, err := entitlements.GetVerifier().Verify(strings.ToLower(purchasePayload.Platform), purchasePayload.Receipt)
+	if errors.Is(err, entitlements.ErrInvalidReceipt) || (err == nil && purchase.Plan == "") {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadRequest, "Invalid receipt"))
+		return
+	}
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusBadGateway, "Error verifying receipt"))
+		return
+	}
+
+	err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+		var existing models.Subscription
+		err := tx.Where("transaction_id = ?", purchase.TransactionID).First(&existing).Error
+		if err == nil {
+			if existing.UserID != contextUser.ID {
+				return utils.NewAppError(http.StatusConflict, "Receipt already redeemed")
+			}
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		endsAt := purchase.EndsAt
+		return tx.Create(&models.Subscription{
+			UserID:        contextUser.ID,
+			Plan:          purchase.Plan,
+			Status:        models.SubscriptionStatusActive,
+			Source:        source,
+			TransactionID: &purchase.TransactionID,
+			StartsAt:      purchase.StartsAt,
+			EndsAt:        &endsAt,
+		}).Error
+	})
+	if err != nil {
+		writeTransactionError(w, err, "Error creating subscription")
+		return
+	}
+
+	response, err := getSubscriptionResponse(contextUser.ID)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error fetching subscription"))
+		return
+	}
+
+	utils.WriteSuccessResponse(w, http.StatusCreated, response)
+}
+
+func GrantUserPlan(w http.ResponseWriter, r *http.Request) {
+	adminUserAction(w, r, models.ModerationActionGrantPlan, func(tx *gorm.DB, user models.User, payload adminActionPayload) error {
+		if payload.Plan == entitlements.PlanFree || !entitlements.IsPlan(payload.Plan) {
+			return utils.NewAppError(http.StatusBadRequest, "Plan must be one of: plus, gold")
+		}
+		if payload.Days < 0 {
+			return utils.NewAppError(http.StatusBadRequest, "days can't be negative")
+		}
+
+		// Plans granted without a number of days never expire
+		now := time.Now()
+		subscription := models.Subscription{
+			UserID:   user.ID,
+			Plan:     payload.Plan,
+			Status:   models.SubscriptionStatusActive,
+			Source:   models.SubscriptionSourceAdmin,
+			StartsAt: now,
+		}
+		if payload.Days > 0 {
+			endsAt := now.AddDate(0, 0, payload.Days)
+			subscription.EndsAt = &endsAt
+		}
+		return tx.Create(&subscription).Error
+	})
+}
+
+// RevokeUserPlan cancels every active subscription of the user, moving them back to the free plan
+func RevokeUserPlan(w http.ResponseWriter, r *http.Request) {
+	adminUserAction(w, r, models.ModerationActionRevokePlan, func(tx *gorm.DB, user models.User, payload adminActionPayload) error {
+		now := time.Now()
+		return tx.Model(&models.Subscription{}).
+			Where("user_id = ? AND status = ? AND (ends_at IS NULL OR ends_at > ?)", user.ID, models.SubscriptionStatusActive, now).
+			Updates(map[string]interface{}{"status": models.SubscriptionStatusCancelled, "ends_at": now}).Error
+	})
+}
+
+func getSubscriptionResponse(userID uint64) (SubscriptionResponse, error) {
+	current, err := entitlements.Current(core.GetDb(), userID, time.Now())
+	if err != nil {
+		return SubscriptionResponse{}, err
+	}
+
+	response := SubscriptionResponse{Set: entitlements.ForPlan(entitlements.PlanFree), Subscription: current}
+	if current != nil {
+		response.Set = entitlements.ForPlan(current.Plan)
+	}
+	return response, nil
+}
+
+// getEntitlements returns the entitlements of the user's current plan
+func getEntitlements(userID uint64) (entitlements.Set, error) {
+	return entitlements.Load(core.GetDb(), userID, time.Now())
+}
+
+// canSeeLikers reports whether the user can see who sent their pending likes
+func canSeeLikers(userID uint64) bool {
+	if !core.AppConfig.LIKES_INBOX_COUNT_ONLY {
+		return true
+	}
+
+	userEntitlements, err := getEntitlements(userID)
+	if err != nil {
+		fmt.Printf("Error loading entitlements: %v\n", err)
+		return false
+	}
+	return userEntitlements.Has(entitlements.SeeLikes)
+}
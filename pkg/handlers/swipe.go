@@ -2,13 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"dating-app/pkg/core"
 	"dating-app/pkg/models"
 	"dating-app/pkg/utils"
+
+	"gorm.io/gorm"
 )
 
 type UserSwipeMatchedResonse struct {
@@ -35,6 +40,8 @@ func UserSwipe(w http.ResponseWriter, r *http.Request) {
 	var swipePayload struct {
 		TargetID  uint64 `json:"targetID"`
 		SwipeType string `json:"swipeType"`
+		// UseCredits agrees to pay for the like with credits when it's over the daily limit
+		UseCredits bool `json:"useCredits"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&swipePayload)
@@ -57,17 +64,81 @@ func UserSwipe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Swipes between users hidden from each other are silently dropped
+	// answering like a regular swipe so the swiper can't tell they were blocked
+	visible, err := isVisibleTo(contextUser.ID, targetUser.ID)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating swipe record"))
+		return
+	}
+	if !visible {
+		utils.WriteSuccessResponse(w, http.StatusOK, UserSwipeNotMatchedResonse{Matched: false})
+		return
+	}
+
 	swipe := models.Swipe{
 		SwiperID:  contextUser.ID,
 		TargetID:  swipePayload.TargetID,
 		SwipeType: swipePayload.SwipeType,
 	}
-	createSwipeErr := core.GetDb().Create(&swipe)
-	if createSwipeErr.Error != nil {
+	userEntitlements, err := getEntitlements(contextUser.ID)
+	if err != nil {
+		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating swipe record"))
+		return
+	}
+	likeLimit := dailyLikeLimit(contextUser, userEntitlements)
+
+	// Likes are counted towards the daily limit in the same transaction as the swipe
+	// so a failed swipe doesn't use up a like, likes over the limit are paid with credits
+	// only when the client asked for it so users are never charged without knowing
+	now := time.Now()
+	err = core.GetDb().Transaction(func(tx *gorm.DB) error {
+		overLimit := false
+		if swipe.SwipeType == "YES" {
+			err := takeDailyLike(tx, contextUser, likeLimit, now)
+			if errors.Is(err, errDailyLikeLimitReached) {
+				overLimit = true
+			} else if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&swipe).Error; err != nil {
+			return err
+		}
+		if overLimit {
+			if !swipePayload.UseCredits {
+				return errDailyLikeLimitReached
+			}
+			return payExtraLike(tx, contextUser.ID, swipe.ID)
+		}
+		return nil
+	})
+	if errors.Is(err, errDailyLikeLimitReached) {
+		_, resetAt := likeLimitDay(now)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(resetAt.Sub(now).Seconds()))))
+		utils.WriteErrorResponse(w, utils.NewAppErrorWithDetails(http.StatusTooManyRequests, "Daily like limit reached", DailyLikeLimitDetails{
+			Limit:         likeLimit,
+			ResetAt:       resetAt,
+			ExtraLikeCost: core.AppConfig.CREDITS_PER_EXTRA_LIKE,
+		}))
+		return
+	}
+	if err != nil {
 		utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, "Error creating swipe record"))
 		return
 	}
 
+	// Look for bot-like swiping patterns
+	checkSwipeBehaviour(contextUser.ID)
+
+	// Shadow-banned users swipe like everyone else but their swipes have no effect on other users,
+	// they don't count towards the attractiveness score, never create a match and are never announced
+	if contextUser.ShadowBanned {
+		utils.WriteSuccessResponse(w, http.StatusOK, UserSwipeNotMatchedResonse{Matched: false})
+		return
+	}
+
 	// Update the Target user attractiveness score
 	// Using Goroutines to run the function in the background as it's not critical to the response
 	go func(targetUser models.User, swipeType string) {
@@ -90,6 +161,7 @@ func UserSwipe(w http.ResponseWriter, r *http.Request) {
 					User2ID: swipe.TargetID,
 				}
 				core.GetDb().Create(&match)
+				publishMatchCreated(match)
 				userSwipeMatchedResonse := UserSwipeMatchedResonse{Matched: true, MatchID: match.ID}
 				utils.WriteSuccessResponse(w, http.StatusOK, userSwipeMatchedResonse)
 				return
@@ -100,6 +172,11 @@ func UserSwipe(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Let the target user know someone liked them
+	if swipe.SwipeType == "YES" {
+		publishLikeReceived(swipe)
+	}
+
 	// The swiper selected NO so there's no need to check for a match with the target user
 	createdUserResponse := UserSwipeNotMatchedResonse{Matched: false}
 	utils.WriteSuccessResponse(w, http.StatusOK, createdUserResponse)
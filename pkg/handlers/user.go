@@ -47,6 +47,31 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	newUser.Password = string(hashedPassword)
 
+	// New accounts are always active regular users whatever the payload says
+	newUser.Status = models.UserStatusActive
+	newUser.SuspendedUntil = nil
+	newUser.Role = models.RoleUser
+
+	// The gender is written freely at sign up and mapped to an identity of the list,
+	// users can choose another identity from their profile afterwards
+	newUser.GenderIdentityID = nil
+	newUser.GenderGroup = ""
+	newUser.GenderCustom = ""
+	newUser.ShowGender = true
+	if newUser.Gender != "" {
+		identities, err := getGenderIdentities()
+		if err != nil {
+			utils.WriteErrorResponse(w, utils.NewAppError(http.StatusInternalServerError, fmt.Sprintf("Error creating user: %v", err)))
+			return
+		}
+		slug, custom := models.LegacyGenderSlug(newUser.Gender, identities)
+		for _, identity := range identities {
+			if identity.Slug == slug {
+				applyGenderIdentity(&newUser, identity, custom)
+			}
+		}
+	}
+
 	// Ideally the location latitude and longitude will be recieved from the frontend client
 	// generating random values for now
 	latitude, longitude := utils.GenerateRandomLatLong()
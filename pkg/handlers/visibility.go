@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// visibleTo hides the users the viewer must never see or interact with
+// the users blocked by the viewer, the users who blocked the viewer, the suspended or banned users
+// and the shadow-banned users
+// The column is the user ID column of the query the filter is applied to
+func visibleTo(viewerID uint64, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		blockedIDs := core.GetDb().Model(&models.Block{}).Select("blocked_id").Where("blocker_id = ?", viewerID)
+		blockerIDs := core.GetDb().Model(&models.Block{}).Select("blocker_id").Where("blocked_id = ?", viewerID)
+		restrictedIDs := core.GetDb().Model(&models.User{}).Select("id").
+			Where("status = ? OR (status = ? AND (suspended_until IS NULL OR suspended_until > ?)) OR shadow_banned = ?", models.UserStatusBanned, models.UserStatusSuspended, time.Now(), true)
+
+		return db.
+			Where(fmt.Sprintf("%s NOT IN (?)", column), blockedIDs).
+			Where(fmt.Sprintf("%s NOT IN (?)", column), blockerIDs).
+			Where(fmt.Sprintf("%s NOT IN (?)", column), restrictedIDs)
+	}
+}
+
+// notSwipedBy hides the users the viewer already swiped on
+func notSwipedBy(viewerID uint64, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		swipedIDs := core.GetDb().Model(&models.Swipe{}).Select("target_id").Where("swiper_id = ?", viewerID)
+
+		return db.Where(fmt.Sprintf("%s NOT IN (?)", column), swipedIDs)
+	}
+}
+
+// isVisibleTo reports whether the target user passes the visibility filter of the viewer
+func isVisibleTo(viewerID, targetID uint64) (bool, error) {
+	var count int64
+	err := core.GetDb().Model(&models.User{}).
+		Where("users.id = ?", targetID).
+		Scopes(visibleTo(viewerID, "users.id")).
+		Count(&count).Error
+
+	return count > 0, err
+}
@@ -0,0 +1,178 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"dating-app/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// System accounts, credits bought or granted come out of them and spent credits go into them
+const (
+	AccountPurchases = "system:purchases"
+	AccountGrants    = "system:grants"
+	AccountSpending  = "system:spending"
+)
+
+var (
+	ErrInsufficientCredits = errors.New("insufficient credits")
+	ErrUnbalanced          = errors.New("ledger entries don't sum up to zero")
+	ErrInvalidAmount       = errors.New("amount must be positive")
+)
+
+// Posting is a movement of credits in a transaction being posted
+// UserID is set on the postings to the account of a user
+type Posting struct {
+	Account string
+	UserID  uint64
+	Amount  int64
+}
+
+// UserAccount returns the name of the account holding the credits of the user
+func UserAccount(userID uint64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func userPosting(userID uint64, amount int64) Posting {
+	return Posting{Account: UserAccount(userID), UserID: userID, Amount: amount}
+}
+
+// account loads the named account, creating it on first use
+func account(tx *gorm.DB, name string, userID *uint64) (models.LedgerAccount, error) {
+	account := models.LedgerAccount{Name: name, UserID: userID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		return account, err
+	}
+	err := tx.Where("name = ?", name).First(&account).Error
+	return account, err
+}
+
+// Post records a transaction made of postings summing up to zero and updates the balances
+// The balance of user accounts can't go below zero, ErrInsufficientCredits is returned instead
+// When a transaction with the same idempotency key exists it is returned without posting anything,
+// created tells both cases apart
+// Post must run in a database transaction so a failed posting doesn't leave partial entries
+func Post(tx *gorm.DB, kind string, idempotencyKey string, reference string, postings []Posting) (models.LedgerTransaction, bool, error) {
+	var transaction models.LedgerTransaction
+
+	if err := checkPostings(postings); err != nil {
+		return transaction, false, err
+	}
+
+	if idempotencyKey != "" {
+		err := tx.Preload("Entries").Where("idempotency_key = ?", idempotencyKey).First(&transaction).Error
+		if err == nil {
+			return transaction, false, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return transaction, false, err
+		}
+		transaction.IdempotencyKey = &idempotencyKey
+	}
+
+	transaction.Kind = kind
+	transaction.Reference = reference
+	if err := tx.Omit("Entries").Create(&transaction).Error; err != nil {
+		return transaction, false, err
+	}
+
+	for _, posting := range postings {
+		var userID *uint64
+		if posting.UserID != 0 {
+			userID = &posting.UserID
+		}
+
+		account, err := account(tx, posting.Account, userID)
+		if err != nil {
+			return transaction, false, err
+		}
+
+		// The balance is only debited when it covers the amount so
+		// concurrent spending can't overdraw the account
+		update := tx.Model(&models.LedgerAccount{}).Where("id = ?", account.ID)
+		if userID != nil && posting.Amount < 0 {
+			update = update.Where("balance >= ?", -posting.Amount)
+		}
+		result := update.Update("balance", gorm.Expr("balance + ?", posting.Amount))
+		if result.Error != nil {
+			return transaction, false, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return transaction, false, ErrInsufficientCredits
+		}
+
+		entry := models.LedgerEntry{TransactionID: transaction.ID, AccountID: account.ID, Amount: posting.Amount}
+		if err := tx.Create(&entry).Error; err != nil {
+			return transaction, false, err
+		}
+		transaction.Entries = append(transaction.Entries, entry)
+	}
+
+	return transaction, true, nil
+}
+
+// checkPostings makes sure the postings move credits between at least two accounts and sum up to zero
+// The sum is checked for overflows, huge amounts could otherwise wrap around to zero
+func checkPostings(postings []Posting) error {
+	if len(postings) < 2 {
+		return ErrUnbalanced
+	}
+
+	var sum int64
+	for _, posting := range postings {
+		if posting.Amount == 0 {
+			return ErrInvalidAmount
+		}
+		if (posting.Amount > 0 && sum > math.MaxInt64-posting.Amount) || (posting.Amount < 0 && sum < math.MinInt64-posting.Amount) {
+			return ErrUnbalanced
+		}
+		sum += posting.Amount
+	}
+	if sum != 0 {
+		return ErrUnbalanced
+	}
+	return nil
+}
+
+// Credit adds purchased or granted credits to the user, keyed by the purchase or grant ID
+func Credit(tx *gorm.DB, userID uint64, amount int64, kind string, idempotencyKey string, reference string) (models.LedgerTransaction, bool, error) {
+	if amount <= 0 {
+		return models.LedgerTransaction{}, false, ErrInvalidAmount
+	}
+
+	source := AccountGrants
+	if kind == models.LedgerTransactionPurchase {
+		source = AccountPurchases
+	}
+	return Post(tx, kind, idempotencyKey, reference, []Posting{
+		{Account: source, Amount: -amount},
+		userPosting(userID, amount),
+	})
+}
+
+// Spend takes credits from the user, failing with ErrInsufficientCredits when their balance is too low
+func Spend(tx *gorm.DB, userID uint64, amount int64, reference string) (models.LedgerTransaction, error) {
+	if amount <= 0 {
+		return models.LedgerTransaction{}, ErrInvalidAmount
+	}
+
+	transaction, _, err := Post(tx, models.LedgerTransactionSpend, "", reference, []Posting{
+		userPosting(userID, -amount),
+		{Account: AccountSpending, Amount: amount},
+	})
+	return transaction, err
+}
+
+// Balance returns the credits held by the user
+func Balance(db *gorm.DB, userID uint64) (int64, error) {
+	var account models.LedgerAccount
+	err := db.Where("name = ?", UserAccount(userID)).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return account.Balance, err
+}
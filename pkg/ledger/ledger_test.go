@@ -0,0 +1,66 @@
+package ledger
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCheckPostings(t *testing.T) {
+	tests := []struct {
+		name     string
+		postings []Posting
+		want     error
+	}{
+		{"credit", []Posting{{Account: AccountPurchases, Amount: -100}, userPosting(1, 100)}, nil},
+		{"spend", []Posting{userPosting(1, -1), {Account: AccountSpending, Amount: 1}}, nil},
+		{"split", []Posting{userPosting(1, -10), userPosting(2, 6), {Account: AccountSpending, Amount: 4}}, nil},
+		{"no postings", nil, ErrUnbalanced},
+		{"single posting", []Posting{userPosting(1, 0)}, ErrUnbalanced},
+		{"money out of thin air", []Posting{{Account: AccountGrants, Amount: -100}, userPosting(1, 101)}, ErrUnbalanced},
+		{"money lost", []Posting{userPosting(1, -5), {Account: AccountSpending, Amount: 4}}, ErrUnbalanced},
+		{"zero posting", []Posting{userPosting(1, 0), {Account: AccountSpending, Amount: 0}}, ErrInvalidAmount},
+		{
+			"overflow wrapping around to zero",
+			[]Posting{userPosting(1, math.MaxInt64), userPosting(2, math.MaxInt64), {Account: AccountGrants, Amount: 2}},
+			ErrUnbalanced,
+		},
+		{
+			"underflow wrapping around to zero",
+			[]Posting{{Account: AccountGrants, Amount: math.MinInt64}, {Account: AccountGrants, Amount: math.MinInt64}, userPosting(1, 1)},
+			ErrUnbalanced,
+		},
+		{"largest balanced amounts", []Posting{{Account: AccountGrants, Amount: -math.MaxInt64}, userPosting(1, math.MaxInt64)}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkPostings(test.postings); !errors.Is(err, test.want) {
+				t.Errorf("checkPostings() = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+// The amounts are checked before the database is touched, so no database is needed
+func TestInvalidAmountsAreRejected(t *testing.T) {
+	for _, amount := range []int64{0, -1, math.MinInt64} {
+		if _, _, err := Credit(nil, 1, amount, "grant", "", ""); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Credit(%d) error = %v, want ErrInvalidAmount", amount, err)
+		}
+		if _, err := Spend(nil, 1, amount, ""); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Spend(%d) error = %v, want ErrInvalidAmount", amount, err)
+		}
+	}
+
+	if _, _, err := Post(nil, "grant", "", "", []Posting{{Account: AccountGrants, Amount: -1}, userPosting(1, 2)}); !errors.Is(err, ErrUnbalanced) {
+		t.Errorf("Post() error = %v, want ErrUnbalanced", err)
+	}
+}
+
+func TestUserAccount(t *testing.T) {
+	posting := userPosting(42, 5)
+	if posting.Account != "user:42" || posting.UserID != 42 || posting.Amount != 5 {
+		t.Errorf("userPosting(42, 5) = %+v", posting)
+	}
+}
@@ -0,0 +1,44 @@
+package ledger
+
+import (
+	"gorm.io/gorm"
+)
+
+// Mismatch is an account whose cached balance differs from the sum of its entries
+type Mismatch struct {
+	Account    string
+	Balance    int64
+	EntriesSum int64
+}
+
+// Report lists the inconsistencies found in the ledger, an empty report means the ledger is consistent
+type Report struct {
+	Mismatches []Mismatch
+	// UnbalancedTransactions are the IDs of the transactions whose entries don't sum up to zero
+	UnbalancedTransactions []uint64
+}
+
+func (r Report) OK() bool {
+	return len(r.Mismatches) == 0 && len(r.UnbalancedTransactions) == 0
+}
+
+// Reconcile checks the cached balances against the entries and the entries of every transaction against each other
+func Reconcile(db *gorm.DB) (Report, error) {
+	var report Report
+
+	err := db.Table("ledger_accounts").
+		Select("ledger_accounts.name AS account, ledger_accounts.balance, COALESCE(SUM(ledger_entries.amount), 0) AS entries_sum").
+		Joins("LEFT JOIN ledger_entries ON ledger_entries.account_id = ledger_accounts.id").
+		Group("ledger_accounts.id").
+		Having("ledger_accounts.balance <> COALESCE(SUM(ledger_entries.amount), 0)").
+		Scan(&report.Mismatches).Error
+	if err != nil {
+		return report, err
+	}
+
+	err = db.Table("ledger_entries").
+		Group("transaction_id").
+		Having("SUM(amount) <> 0").
+		Pluck("transaction_id", &report.UnbalancedTransactions).Error
+	return report, err
+}
@@ -0,0 +1,81 @@
+package matching
+
+import (
+	"math"
+)
+
+// Interest weightings
+const (
+	// WeightingIDF weights the interests by their inverse document frequency,
+	// sharing a rare interest says more about two users than sharing a popular one
+	WeightingIDF = "idf"
+	// WeightingUniform gives every interest the same weight, the overlap is the plain Jaccard index
+	WeightingUniform = "uniform"
+)
+
+// InterestStats are the number of users having each interest, out of the users having any interest
+type InterestStats struct {
+	Users  int64
+	Counts map[uint64]int64
+}
+
+// InterestWeights gives every interest its weight in the overlap of two users,
+// the weights set by the admins win over the weighting
+type InterestWeights struct {
+	weighting string
+	stats     InterestStats
+	overrides map[uint64]float64
+}
+
+func NewInterestWeights(weighting string, stats InterestStats, overrides map[uint64]float64) InterestWeights {
+	return InterestWeights{weighting: weighting, stats: stats, overrides: overrides}
+}
+
+// Weight returns the weight of the interest
+func (w InterestWeights) Weight(interestID uint64) float64 {
+	if weight, ok := w.overrides[interestID]; ok {
+		return weight
+	}
+	if w.weighting == WeightingUniform {
+		return 1
+	}
+
+	// Smoothed so interests everybody has still weigh something
+	users := float64(w.stats.Users)
+	count := float64(w.stats.Counts[interestID])
+	return math.Log((users+1)/(count+1)) + 1
+}
+
+// InterestOverlap is the weighted Jaccard index of the interests of two users: the weight
+// of the shared interests over the weight of all their interests, between 0 and 1
+func InterestOverlap(a, b []uint64, weights InterestWeights) float64 {
+	inA := map[uint64]bool{}
+	for _, interestID := range a {
+		inA[interestID] = true
+	}
+
+	var shared, union float64
+	seen := map[uint64]bool{}
+	for _, interestID := range b {
+		if seen[interestID] {
+			continue
+		}
+		seen[interestID] = true
+
+		weight := weights.Weight(interestID)
+		union += weight
+		if inA[interestID] {
+			shared += weight
+		}
+	}
+	for interestID := range inA {
+		if !seen[interestID] {
+			union += weights.Weight(interestID)
+		}
+	}
+
+	if union <= 0 {
+		return 0
+	}
+	return shared / union
+}
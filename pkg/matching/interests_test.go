@@ -0,0 +1,74 @@
+package matching
+
+import (
+	"math"
+	"testing"
+)
+
+// 100 users, everybody but one likes the interest 1, a few like the interest 2 and nobody the interest 3
+var interestStats = InterestStats{Users: 100, Counts: map[uint64]int64{1: 99, 2: 9}}
+
+func TestInterestWeight(t *testing.T) {
+	tests := []struct {
+		name      string
+		weighting string
+		overrides map[uint64]float64
+		interest  uint64
+		want      float64
+	}{
+		{"popular interest", WeightingIDF, nil, 1, math.Log(101.0/100) + 1},
+		{"rare interest", WeightingIDF, nil, 2, math.Log(101.0/10) + 1},
+		{"interest nobody has", WeightingIDF, nil, 3, math.Log(101) + 1},
+		{"uniform", WeightingUniform, nil, 2, 1},
+		{"override wins over idf", WeightingIDF, map[uint64]float64{2: 0.5}, 2, 0.5},
+		{"override wins over uniform", WeightingUniform, map[uint64]float64{2: 3}, 2, 3},
+		{"override of another interest", WeightingIDF, map[uint64]float64{2: 0.5}, 1, math.Log(101.0/100) + 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			weights := NewInterestWeights(test.weighting, interestStats, test.overrides)
+			if got := weights.Weight(test.interest); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("Weight(%d) = %v, want %v", test.interest, got, test.want)
+			}
+		})
+	}
+}
+
+func TestInterestOverlap(t *testing.T) {
+	idf := NewInterestWeights(WeightingIDF, interestStats, nil)
+	uniform := NewInterestWeights(WeightingUniform, interestStats, nil)
+	popular, rare := idf.Weight(1), idf.Weight(2)
+
+	tests := []struct {
+		name    string
+		a, b    []uint64
+		weights InterestWeights
+		want    float64
+	}{
+		{"both empty", nil, nil, idf, 0},
+		{"one empty", []uint64{1, 2}, nil, idf, 0},
+		{"same interests", []uint64{1, 2}, []uint64{2, 1}, idf, 1},
+		{"nothing shared", []uint64{1}, []uint64{2}, idf, 0},
+		{"uniform is the jaccard index", []uint64{1, 2}, []uint64{2, 3}, uniform, 1.0 / 3},
+		{"sharing the rare interest", []uint64{1, 2}, []uint64{2}, idf, rare / (popular + rare)},
+		{"sharing the popular interest", []uint64{1, 2}, []uint64{1}, idf, popular / (popular + rare)},
+		{"duplicates count once", []uint64{1, 1, 2}, []uint64{2, 2}, idf, rare / (popular + rare)},
+		{"zero overrides", []uint64{1}, []uint64{1}, NewInterestWeights(WeightingIDF, interestStats, map[uint64]float64{1: 0}), 0},
+		{
+			"overrides are used",
+			[]uint64{1, 2},
+			[]uint64{1},
+			NewInterestWeights(WeightingUniform, interestStats, map[uint64]float64{1: 3}),
+			0.75,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := InterestOverlap(test.a, test.b, test.weights); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("InterestOverlap(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+			}
+		})
+	}
+}
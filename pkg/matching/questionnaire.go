@@ -0,0 +1,75 @@
+package matching
+
+import (
+	"math"
+	"slices"
+
+	"dating-app/pkg/models"
+)
+
+// importanceWeights are the points a question is worth for the user depending on its importance,
+// a mandatory question outweighs everything else so a single unacceptable answer sinks the match
+var importanceWeights = map[string]float64{
+	models.ImportanceIrrelevant: 0,
+	models.ImportanceALittle:    1,
+	models.ImportanceSomewhat:   10,
+	models.ImportanceVery:       50,
+	models.ImportanceMandatory:  250,
+}
+
+// QuestionnaireMatch is the match percentage of two users and the number of questions they both answered
+type QuestionnaireMatch struct {
+	Percentage      int
+	CommonQuestions int
+}
+
+// satisfaction returns how much the answers of the other user satisfy the user, between 0 and 1,
+// the share of the points of the common questions earned by answering acceptably
+func satisfaction(answers map[uint64]models.QuestionAnswer, otherAnswers map[uint64]models.QuestionAnswer) float64 {
+	var earned, possible float64
+	for questionID, answer := range answers {
+		otherAnswer, ok := otherAnswers[questionID]
+		if !ok {
+			continue
+		}
+
+		weight := importanceWeights[answer.Importance]
+		possible += weight
+		if slices.Contains(answer.Acceptable, otherAnswer.Answer) {
+			earned += weight
+		}
+	}
+
+	// Only irrelevant questions in common, nothing the other user could do wrong
+	if possible == 0 {
+		return 1
+	}
+	return earned / possible
+}
+
+// MatchPercentage computes the match percentage of two users like OkCupid does: the geometric mean
+// of how much each user satisfies the other, minus a margin of error of one over the number of
+// common questions so that a few lucky answers don't make a perfect match
+func MatchPercentage(a, b []models.QuestionAnswer) QuestionnaireMatch {
+	answersA := map[uint64]models.QuestionAnswer{}
+	for _, answer := range a {
+		answersA[answer.QuestionID] = answer
+	}
+	answersB := map[uint64]models.QuestionAnswer{}
+	common := 0
+	for _, answer := range b {
+		answersB[answer.QuestionID] = answer
+		if _, ok := answersA[answer.QuestionID]; ok {
+			common++
+		}
+	}
+	if common == 0 {
+		return QuestionnaireMatch{}
+	}
+
+	match := math.Sqrt(satisfaction(answersA, answersB)*satisfaction(answersB, answersA)) - 1/float64(common)
+	return QuestionnaireMatch{
+		Percentage:      int(math.Round(math.Max(match, 0) * 100)),
+		CommonQuestions: common,
+	}
+}
@@ -0,0 +1,136 @@
+package matching
+
+import (
+	"testing"
+
+	"dating-app/pkg/models"
+)
+
+func answer(questionID uint64, value int, acceptable []int, importance string) models.QuestionAnswer {
+	return models.QuestionAnswer{QuestionID: questionID, Answer: value, Acceptable: acceptable, Importance: importance}
+}
+
+// agreeing returns answers to the questions 1 to count, all answering 0 and accepting 0
+func agreeing(count int, importance string) []models.QuestionAnswer {
+	answers := []models.QuestionAnswer{}
+	for i := 1; i <= count; i++ {
+		answers = append(answers, answer(uint64(i), 0, []int{0}, importance))
+	}
+	return answers
+}
+
+func TestMatchPercentage(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []models.QuestionAnswer
+		want QuestionnaireMatch
+	}{
+		{
+			name: "no common questions",
+			a:    []models.QuestionAnswer{answer(1, 0, []int{0}, models.ImportanceVery)},
+			b:    []models.QuestionAnswer{answer(2, 0, []int{0}, models.ImportanceVery)},
+			want: QuestionnaireMatch{},
+		},
+		{
+			name: "no answers",
+			a:    nil,
+			b:    agreeing(3, models.ImportanceVery),
+			want: QuestionnaireMatch{},
+		},
+		{
+			// The margin of error of a single question takes the whole match
+			name: "one question in agreement",
+			a:    agreeing(1, models.ImportanceVery),
+			b:    agreeing(1, models.ImportanceVery),
+			want: QuestionnaireMatch{Percentage: 0, CommonQuestions: 1},
+		},
+		{
+			name: "ten questions in agreement",
+			a:    agreeing(10, models.ImportanceVery),
+			b:    agreeing(10, models.ImportanceSomewhat),
+			want: QuestionnaireMatch{Percentage: 90, CommonQuestions: 10},
+		},
+		{
+			name: "hundred questions in agreement",
+			a:    agreeing(100, models.ImportanceALittle),
+			b:    agreeing(100, models.ImportanceVery),
+			want: QuestionnaireMatch{Percentage: 99, CommonQuestions: 100},
+		},
+		{
+			// Only the questions answered by both count
+			name: "partly common questions",
+			a:    agreeing(4, models.ImportanceVery),
+			b:    append(agreeing(2, models.ImportanceVery), answer(7, 0, []int{0}, models.ImportanceVery)),
+			want: QuestionnaireMatch{Percentage: 50, CommonQuestions: 2},
+		},
+		{
+			// a is half satisfied and b fully: sqrt(0.5) - 1/4 = 0.457
+			name: "geometric mean",
+			a:    agreeing(4, models.ImportanceVery),
+			b: []models.QuestionAnswer{
+				answer(1, 0, []int{0}, models.ImportanceVery),
+				answer(2, 0, []int{0}, models.ImportanceVery),
+				answer(3, 1, []int{0}, models.ImportanceVery),
+				answer(4, 1, []int{0}, models.ImportanceVery),
+			},
+			want: QuestionnaireMatch{Percentage: 46, CommonQuestions: 4},
+		},
+		{
+			// a earns 90 of the 340 points: sqrt(90/340) - 1/10 = 0.414
+			name: "unacceptable answer to a mandatory question",
+			a:    append([]models.QuestionAnswer{answer(1, 0, []int{0}, models.ImportanceMandatory)}, agreeing(10, models.ImportanceSomewhat)[1:]...),
+			b:    append([]models.QuestionAnswer{answer(1, 1, []int{0, 1}, models.ImportanceMandatory)}, agreeing(10, models.ImportanceSomewhat)[1:]...),
+			want: QuestionnaireMatch{Percentage: 41, CommonQuestions: 10},
+		},
+		{
+			// a only has irrelevant questions so b can't do anything wrong
+			name: "irrelevant questions only",
+			a: []models.QuestionAnswer{
+				answer(1, 0, []int{0}, models.ImportanceIrrelevant),
+				answer(2, 0, []int{0}, models.ImportanceIrrelevant),
+			},
+			b: []models.QuestionAnswer{
+				answer(1, 1, []int{0}, models.ImportanceVery),
+				answer(2, 1, []int{0}, models.ImportanceVery),
+			},
+			want: QuestionnaireMatch{Percentage: 50, CommonQuestions: 2},
+		},
+		{
+			// The unacceptable answer to the irrelevant question is worth no points
+			name: "unacceptable answer to an irrelevant question",
+			a: []models.QuestionAnswer{
+				answer(1, 0, []int{0}, models.ImportanceVery),
+				answer(2, 0, []int{0}, models.ImportanceIrrelevant),
+			},
+			b: []models.QuestionAnswer{
+				answer(1, 0, []int{0}, models.ImportanceVery),
+				answer(2, 1, []int{0, 1}, models.ImportanceVery),
+			},
+			want: QuestionnaireMatch{Percentage: 50, CommonQuestions: 2},
+		},
+		{
+			name: "no acceptable answers",
+			a:    agreeing(5, models.ImportanceVery),
+			b: []models.QuestionAnswer{
+				answer(1, 1, []int{0}, models.ImportanceVery),
+				answer(2, 1, []int{0}, models.ImportanceVery),
+				answer(3, 1, []int{0}, models.ImportanceVery),
+				answer(4, 1, []int{0}, models.ImportanceVery),
+				answer(5, 1, []int{0}, models.ImportanceVery),
+			},
+			want: QuestionnaireMatch{Percentage: 0, CommonQuestions: 5},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := MatchPercentage(test.a, test.b); got != test.want {
+				t.Errorf("MatchPercentage(a, b) = %+v, want %+v", got, test.want)
+			}
+			// The match percentage is symmetric
+			if got := MatchPercentage(test.b, test.a); got != test.want {
+				t.Errorf("MatchPercentage(b, a) = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
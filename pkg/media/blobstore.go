@@ -0,0 +1,98 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps the uploaded files, addressed by slash separated keys
+// An object storage backed implementation (like S3) is expected to replace the local one in production
+type BlobStore interface {
+	Put(key string, data []byte) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// LocalBlobStore keeps the files in a directory of the local filesystem
+type LocalBlobStore struct {
+	Root string
+}
+
+// path returns the file of the key, keys can't escape the root directory
+func (s *LocalBlobStore) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid blob key: %s", key)
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(cleaned)), nil
+}
+
+func (s *LocalBlobStore) Put(key string, data []byte) error {
+	file, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial file
+	temporary := file + ".tmp"
+	if err := os.WriteFile(temporary, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temporary, file)
+}
+
+func (s *LocalBlobStore) Open(key string) (io.ReadCloser, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return reader, err
+}
+
+func (s *LocalBlobStore) Delete(key string) error {
+	file, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// NewBlobStore creates the blob store matching the given kind (local)
+func NewBlobStore(kind string, localPath string) (BlobStore, error) {
+	switch kind {
+	case "", "local":
+		return &LocalBlobStore{Root: localPath}, nil
+	default:
+		return nil, fmt.Errorf("unknown blob store: %s", kind)
+	}
+}
+
+var store BlobStore = &LocalBlobStore{Root: "media"}
+
+func SetBlobStore(s BlobStore) {
+	store = s
+}
+
+func GetBlobStore() BlobStore {
+	return store
+}
@@ -0,0 +1,82 @@
+package media
+
+import (
+	"strings"
+
+	"dating-app/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IndexPhotoHashes stores the bands of the pHash of the photo for the duplicate lookups
+func IndexPhotoHashes(tx *gorm.DB, photo models.Photo) error {
+	bands := Bands(photo.PHash)
+	rows := make([]models.PhotoHashBand, len(bands))
+	for i, value := range bands {
+		rows[i] = models.PhotoHashBand{PhotoID: photo.ID, Band: uint8(i), Value: value, UserID: photo.UserID}
+	}
+	return tx.Create(&rows).Error
+}
+
+// FindDuplicatePhotos returns a flag for every photo of another account whose hashes are both within maxDistance of the photo
+// Only the indexed photos sharing a band close to the ones of the photo are compared, see Bands
+// The later photo of each pair is the flagged one and the earlier one the matched one
+func FindDuplicatePhotos(db *gorm.DB, photo models.Photo, maxDistance int) ([]models.DuplicatePhotoFlag, error) {
+	if maxDistance < 0 {
+		return nil, nil
+	}
+	radius := maxDistance / HashBands
+
+	conditions := []string{}
+	args := []interface{}{}
+	for i, value := range Bands(photo.PHash) {
+		conditions = append(conditions, "(band = ? AND value IN ?)")
+		args = append(args, i, BandNeighbours(value, radius))
+	}
+
+	var candidateIDs []uint64
+	err := db.Model(&models.PhotoHashBand{}).
+		Distinct("photo_id").
+		Where("user_id <> ?", photo.UserID).
+		Where(strings.Join(conditions, " OR "), args...).
+		Pluck("photo_id", &candidateIDs).Error
+	if err != nil || len(candidateIDs) == 0 {
+		return nil, err
+	}
+
+	var candidates []models.Photo
+	if err := db.Where("id IN ?", candidateIDs).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	flags := []models.DuplicatePhotoFlag{}
+	for _, candidate := range candidates {
+		pHashDistance := HammingDistance(photo.PHash, candidate.PHash)
+		dHashDistance := HammingDistance(photo.DHash, candidate.DHash)
+		if pHashDistance > maxDistance || dHashDistance > maxDistance {
+			continue
+		}
+
+		flagged, matched := photo, candidate
+		if candidate.ID > photo.ID {
+			flagged, matched = candidate, photo
+		}
+		flags = append(flags, models.DuplicatePhotoFlag{
+			PhotoID:        flagged.ID,
+			UserID:         flagged.UserID,
+			MatchedPhotoID: matched.ID,
+			MatchedUserID:  matched.UserID,
+			PHashDistance:  pHashDistance,
+			DHashDistance:  dHashDistance,
+			Status:         models.DuplicatePhotoFlagStatusOpen,
+		})
+	}
+
+	return flags, nil
+}
+
+// RecordDuplicatePhotoFlag flags the pair of photos for review unless it was already flagged
+func RecordDuplicatePhotoFlag(db *gorm.DB, flag models.DuplicatePhotoFlag) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&flag).Error
+}
@@ -0,0 +1,109 @@
+package media
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// jpegWithOrientation builds the start of a JPEG file holding an EXIF segment with the orientation tag
+func jpegWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+2*12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+
+	// Two entries, the orientation comes after another tag
+	order.PutUint16(tiff[8:], 2)
+	order.PutUint16(tiff[10:], 0x010F) // Make
+	order.PutUint16(tiff[12:], 2)
+	order.PutUint16(tiff[22:], exifOrientationTag)
+	order.PutUint16(tiff[24:], 3) // SHORT
+	order.PutUint32(tiff[26:], 1)
+	order.PutUint16(tiff[30:], orientation)
+
+	return jpegWithSegment(0xE1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+// jpegWithSegment builds the start of a JPEG file with an APP0 segment, the given segment and the start of the image data
+func jpegWithSegment(marker byte, payload []byte) []byte {
+	data := []byte{0xFF, 0xD8}
+	data = append(data, 0xFF, 0xE0, 0x00, 0x07, 'J', 'F', 'I', 'F', 0x00)
+	data = append(data, 0xFF, marker)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(payload)+2))
+	data = append(data, payload...)
+	return append(data, 0xFF, 0xDA, 0x00, 0x02)
+}
+
+func TestExifOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"little endian", jpegWithOrientation(binary.LittleEndian, 6), 6},
+		{"big endian", jpegWithOrientation(binary.BigEndian, 8), 8},
+		{"upright", jpegWithOrientation(binary.BigEndian, 1), 1},
+		{"out of range", jpegWithOrientation(binary.LittleEndian, 9), 1},
+		{"zero", jpegWithOrientation(binary.LittleEndian, 0), 1},
+		{"no exif segment", jpegWithSegment(0xE2, []byte("ICC_PROFILE\x00")), 1},
+		{"not exif", jpegWithSegment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00")), 1},
+		{"invalid tiff header", jpegWithSegment(0xE1, []byte("Exif\x00\x00XX\x00\x2a\x00\x00\x00\x08")), 1},
+		{"ifd out of bounds", jpegWithSegment(0xE1, []byte("Exif\x00\x00MM\x00\x2a\x00\x00\xff\xff")), 1},
+		{"truncated", jpegWithOrientation(binary.BigEndian, 6)[:30], 1},
+		{"png", []byte("\x89PNG\r\n\x1a\n"), 1},
+		{"empty", nil, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := exifOrientation(test.data); got != test.want {
+				t.Errorf("exifOrientation() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image whose pixels hold their index, 0 1 2 on the first row and 3 4 5 on the second
+	src := gradientImage(3, 2)
+	for i := 0; i < 6; i++ {
+		src.Pix[i*4] = uint8(i)
+	}
+
+	tests := []struct {
+		orientation int
+		// Pixel indexes of the oriented image row by row
+		want [][]uint8
+	}{
+		{1, [][]uint8{{0, 1, 2}, {3, 4, 5}}},
+		{2, [][]uint8{{2, 1, 0}, {5, 4, 3}}},
+		{3, [][]uint8{{5, 4, 3}, {2, 1, 0}}},
+		{4, [][]uint8{{3, 4, 5}, {0, 1, 2}}},
+		{5, [][]uint8{{0, 3}, {1, 4}, {2, 5}}},
+		{6, [][]uint8{{3, 0}, {4, 1}, {5, 2}}},
+		{7, [][]uint8{{5, 2}, {4, 1}, {3, 0}}},
+		{8, [][]uint8{{2, 5}, {1, 4}, {0, 3}}},
+	}
+
+	for _, test := range tests {
+		oriented := orient(src, test.orientation)
+		if test.orientation == 1 && oriented != src {
+			t.Errorf("orient(1) allocated a new image")
+		}
+		if oriented.Bounds().Dy() != len(test.want) || oriented.Bounds().Dx() != len(test.want[0]) {
+			t.Errorf("orient(%d) size = %v, want %dx%d", test.orientation, oriented.Bounds().Size(), len(test.want[0]), len(test.want))
+			continue
+		}
+		for y, row := range test.want {
+			for x, want := range row {
+				if got := oriented.Pix[y*oriented.Stride+x*4]; got != want {
+					t.Errorf("orient(%d) pixel (%d, %d) = %d, want %d", test.orientation, x, y, got, want)
+				}
+			}
+		}
+	}
+}
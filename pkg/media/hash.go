@@ -0,0 +1,155 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// Side of the grayscale image the pHash is computed from and of its kept low frequencies
+const (
+	pHashSize    = 32
+	pHashLowSize = 8
+)
+
+// Perceptual hashes are split in bands for the duplicate lookups
+const (
+	HashBands    = 4
+	hashBandBits = 64 / HashBands
+)
+
+// ImageHashes are perceptual hashes of a photo, similar photos have hashes
+// differing by a few bits even after resizing, re-encoding or small edits
+type ImageHashes struct {
+	// PHash keeps the lowest frequencies of the discrete cosine transform of the photo
+	PHash uint64
+	// DHash keeps the direction of the brightness gradient between neighbour pixels
+	DHash uint64
+}
+
+// HashImage computes the perceptual hashes of the photo
+func HashImage(img *image.NRGBA) ImageHashes {
+	return ImageHashes{
+		PHash: pHash(img),
+		DHash: dHash(img),
+	}
+}
+
+// HashEncoded computes the perceptual hashes of an encoded JPEG or PNG photo, like a stored variant
+// The photo is shrunk like the uploads so the hashes can be compared with theirs
+func HashEncoded(data []byte) (ImageHashes, error) {
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ImageHashes{}, ErrInvalidImage
+	}
+	return HashImage(shrink(decoded, Variants[len(Variants)-1].MaxSize)), nil
+}
+
+// HammingDistance counts the bits differing between two hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Bands splits the hash in HashBands bands of 16 bits
+// Two hashes within a distance d always have a band within d / HashBands of each other,
+// so looking up the bands close to the ones of a hash finds every near duplicate
+func Bands(hash uint64) [HashBands]uint16 {
+	var bands [HashBands]uint16
+	for i := range bands {
+		bands[i] = uint16(hash >> (i * hashBandBits))
+	}
+	return bands
+}
+
+// BandNeighbours returns every band value within the radius of the band, the band included
+func BandNeighbours(band uint16, radius int) []uint16 {
+	neighbours := []uint16{band}
+	var flip func(value uint16, from int, left int)
+	flip = func(value uint16, from int, left int) {
+		if left == 0 {
+			return
+		}
+		for bit := from; bit < hashBandBits; bit++ {
+			flipped := value ^ (1 << bit)
+			neighbours = append(neighbours, flipped)
+			flip(flipped, bit+1, left-1)
+		}
+	}
+	flip(band, 0, radius)
+	return neighbours
+}
+
+// grayscale scales the image down and returns the luminance of its pixels row by row
+func grayscale(img *image.NRGBA, width, height int) []float64 {
+	small := img
+	if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+		small = downscale(img, width, height)
+	}
+
+	luminance := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pixel := small.Pix[y*small.Stride+x*4:]
+			luminance[y*width+x] = 0.299*float64(pixel[0]) + 0.587*float64(pixel[1]) + 0.114*float64(pixel[2])
+		}
+	}
+	return luminance
+}
+
+func pHash(img *image.NRGBA) uint64 {
+	pixels := grayscale(img, pHashSize, pHashSize)
+
+	// The cosines of the transform are the same for the rows and the columns
+	var cosines [pHashLowSize][pHashSize]float64
+	for u := 0; u < pHashLowSize; u++ {
+		for x := 0; x < pHashSize; x++ {
+			cosines[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * pHashSize))
+		}
+	}
+
+	// Only the lowest frequencies are needed, the transform is computed for them only
+	coefficients := make([]float64, 0, pHashLowSize*pHashLowSize)
+	for v := 0; v < pHashLowSize; v++ {
+		for u := 0; u < pHashLowSize; u++ {
+			var sum float64
+			for y := 0; y < pHashSize; y++ {
+				for x := 0; x < pHashSize; x++ {
+					sum += pixels[y*pHashSize+x] * cosines[u][x] * cosines[v][y]
+				}
+			}
+			coefficients = append(coefficients, sum)
+		}
+	}
+
+	// The first coefficient is the average brightness, it's left out of the median
+	// so the hash doesn't depend on the exposure of the photo
+	sorted := append([]float64{}, coefficients[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, coefficient := range coefficients {
+		if coefficient > median {
+			hash |= 1 << i
+		}
+	}
+	return hash
+}
+
+func dHash(img *image.NRGBA) uint64 {
+	// One more column than bits so every pixel of a row has a right neighbour
+	const width, height = 9, 8
+	pixels := grayscale(img, width, height)
+
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			if pixels[y*width+x] < pixels[y*width+x+1] {
+				hash |= 1 << (y*(width-1) + x)
+			}
+		}
+	}
+	return hash
+}
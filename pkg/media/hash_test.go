@@ -0,0 +1,161 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+func TestBands(t *testing.T) {
+	hash := uint64(0x0123456789ABCDEF)
+	want := [HashBands]uint16{0xCDEF, 0x89AB, 0x4567, 0x0123}
+	if got := Bands(hash); got != want {
+		t.Errorf("Bands(%x) = %x, want %x", hash, got, want)
+	}
+}
+
+func TestBandNeighbours(t *testing.T) {
+	// Number of values within the radius: the sum of the ways to choose up to radius bits out of 16
+	tests := []struct {
+		radius int
+		want   int
+	}{
+		{0, 1},
+		{1, 17},
+		{2, 137},
+		{3, 697},
+	}
+
+	for _, test := range tests {
+		band := uint16(0xA5C3)
+		neighbours := BandNeighbours(band, test.radius)
+		if len(neighbours) != test.want {
+			t.Errorf("BandNeighbours(radius %d) returned %d values, want %d", test.radius, len(neighbours), test.want)
+		}
+
+		seen := map[uint16]bool{}
+		for _, neighbour := range neighbours {
+			if seen[neighbour] {
+				t.Errorf("BandNeighbours(radius %d) returned %x twice", test.radius, neighbour)
+			}
+			seen[neighbour] = true
+			if distance := bits.OnesCount16(band ^ neighbour); distance > test.radius {
+				t.Errorf("BandNeighbours(radius %d) returned %x at distance %d", test.radius, neighbour, distance)
+			}
+		}
+		if !seen[band] {
+			t.Errorf("BandNeighbours(radius %d) doesn't include the band itself", test.radius)
+		}
+	}
+}
+
+// The band lookup must find every hash a brute force comparison finds within the distance
+func TestBandLookupMatchesBruteForce(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for _, maxDistance := range []int{0, 3, 4, 6, 8, 11} {
+		radius := maxDistance / HashBands
+
+		// Index random hashes and near copies of them by band value
+		hashes := []uint64{}
+		for i := 0; i < 300; i++ {
+			hash := random.Uint64()
+			hashes = append(hashes, hash, flipBits(random, hash, random.Intn(maxDistance+3)))
+		}
+		index := make([]map[uint16][]int, HashBands)
+		for band := range index {
+			index[band] = map[uint16][]int{}
+		}
+		for i, hash := range hashes {
+			for band, value := range Bands(hash) {
+				index[band][value] = append(index[band][value], i)
+			}
+		}
+
+		for i, hash := range hashes {
+			found := map[int]bool{}
+			for band, value := range Bands(hash) {
+				for _, neighbour := range BandNeighbours(value, radius) {
+					for _, j := range index[band][neighbour] {
+						found[j] = true
+					}
+				}
+			}
+
+			for j, other := range hashes {
+				if HammingDistance(hash, other) <= maxDistance && !found[j] {
+					t.Fatalf("distance %d: hash %d at distance %d of hash %d was not found by the band lookup", maxDistance, j, HammingDistance(hash, other), i)
+				}
+			}
+		}
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xFF, 0x0F, 4},
+		{0, ^uint64(0), 64},
+	}
+
+	for _, test := range tests {
+		if got := HammingDistance(test.a, test.b); got != test.want {
+			t.Errorf("HammingDistance(%x, %x) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestHashImageIsStableUnderResizing(t *testing.T) {
+	original := gradientImage(800, 600)
+	resized := fit(original, 160)
+	other := checkerboardImage(800, 600)
+
+	hashes, resizedHashes, otherHashes := HashImage(original), HashImage(resized), HashImage(other)
+	if distance := HammingDistance(hashes.PHash, resizedHashes.PHash); distance > 4 {
+		t.Errorf("pHash distance after resizing = %d, want at most 4", distance)
+	}
+	if distance := HammingDistance(hashes.DHash, resizedHashes.DHash); distance > 4 {
+		t.Errorf("dHash distance after resizing = %d, want at most 4", distance)
+	}
+	if distance := HammingDistance(hashes.PHash, otherHashes.PHash); distance < 10 {
+		t.Errorf("pHash distance between different images = %d, want at least 10", distance)
+	}
+}
+
+// flipBits flips count distinct random bits of the hash
+func flipBits(random *rand.Rand, hash uint64, count int) uint64 {
+	for _, bit := range random.Perm(64)[:count] {
+		hash ^= 1 << bit
+	}
+	return hash
+}
+
+func gradientImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), B: uint8((x + y) % 256), A: 255})
+		}
+	}
+	return img
+}
+
+func checkerboardImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := uint8(0)
+			if (x/100+y/100)%2 == 0 {
+				value = 255
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: value, G: value, B: value, A: 255})
+		}
+	}
+	return img
+}
@@ -0,0 +1,173 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Quality of the encoded variants
+const jpegQuality = 85
+
+// Largest number of pixels of an uploaded photo (40 megapixels), larger images are rejected
+// before decoding them so a small compressed file can't allocate gigabytes of pixels
+const maxSourcePixels = 40_000_000
+
+// Number of photos processed at the same time, every decoded photo can hold up to
+// 160 MB of pixels so the uploads queue up instead of exhausting the memory
+const maxConcurrentProcessing = 4
+
+var processing = make(chan struct{}, maxConcurrentProcessing)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type, only JPEG and PNG are accepted")
+	ErrInvalidImage    = errors.New("invalid image")
+	ErrImageTooLarge   = fmt.Errorf("image can't exceed %d megapixels", maxSourcePixels/1_000_000)
+)
+
+// Variant is a resized version of a photo, MaxSize bounds the longest side
+type Variant struct {
+	Name    string
+	MaxSize int
+}
+
+// Variants generated for every photo, from the smallest to the largest
+var Variants = []Variant{
+	{Name: "thumb", MaxSize: 160},
+	{Name: "medium", MaxSize: 640},
+	{Name: "large", MaxSize: 1280},
+}
+
+// EncodedVariant is a variant ready to be stored
+type EncodedVariant struct {
+	Name   string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// ProcessedImage is an uploaded photo after processing
+type ProcessedImage struct {
+	// Width and Height of the photo once upright
+	Width    int
+	Height   int
+	Hashes   ImageHashes
+	Variants []EncodedVariant
+}
+
+// ProcessImage validates the uploaded photo and generates its variants
+// The variants are re-encoded from the pixels only, which strips every metadata
+// of the original file like the EXIF GPS coordinates and the camera details
+func ProcessImage(data []byte) (*ProcessedImage, error) {
+	var decode func([]byte) (image.Image, error)
+	var decodeConfig func([]byte) (image.Config, error)
+
+	// Trust the content of the file rather than the type announced by the client
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		decode = func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) }
+		decodeConfig = func(data []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(data)) }
+	case "image/png":
+		decode = func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) }
+		decodeConfig = func(data []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(data)) }
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	config, err := decodeConfig(data)
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if int64(config.Width)*int64(config.Height) > maxSourcePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	processing <- struct{}{}
+	defer func() { <-processing }()
+
+	decoded, err := decode(data)
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	// The decoded image is the only full size buffer, it's shrunk to the largest variant
+	// right away and every other step works on the shrunk copy
+	orientation := exifOrientation(data)
+	upright := orient(shrink(decoded, Variants[len(Variants)-1].MaxSize), orientation)
+
+	processed := &ProcessedImage{
+		Width:  config.Width,
+		Height: config.Height,
+		Hashes: HashImage(upright),
+	}
+	// Orientations 5 to 8 swap the width and the height
+	if orientation >= 5 && orientation <= 8 {
+		processed.Width, processed.Height = config.Height, config.Width
+	}
+	for _, variant := range Variants {
+		resized := fit(upright, variant.MaxSize)
+
+		var buffer bytes.Buffer
+		if err := jpeg.Encode(&buffer, resized, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+
+		processed.Variants = append(processed.Variants, EncodedVariant{
+			Name:   variant.Name,
+			Width:  resized.Bounds().Dx(),
+			Height: resized.Bounds().Dy(),
+			Data:   buffer.Bytes(),
+		})
+	}
+
+	return processed, nil
+}
+
+// orient rotates and flips the image according to its EXIF orientation so the variants
+// display upright without their metadata
+func orient(src *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+
+	// Orientations 5 to 8 swap the width and the height
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Flipped horizontally
+				dx, dy = width-1-x, y
+			case 3: // Rotated 180°
+				dx, dy = width-1-x, height-1-y
+			case 4: // Flipped vertically
+				dx, dy = x, height-1-y
+			case 5: // Transposed
+				dx, dy = y, x
+			case 6: // Rotated 90° clockwise
+				dx, dy = height-1-y, x
+			case 7: // Transversed
+				dx, dy = height-1-y, width-1-x
+			case 8: // Rotated 90° counter clockwise
+				dx, dy = y, width-1-x
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:dy*dst.Stride+dx*4+4], src.Pix[y*src.Stride+x*4:y*src.Stride+x*4+4])
+		}
+	}
+
+	return dst
+}
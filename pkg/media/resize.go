@@ -0,0 +1,113 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// shrink flattens the image on a white background, since JPEG has no alpha channel, and scales it
+// down so its longest side is at most maxSize
+// The source is converted a few rows at a time so no second full size buffer is allocated
+func shrink(src image.Image, maxSize int) *image.NRGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := fitSize(width, height, maxSize)
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	// Rows of the source covered by a single destination row, flattened over white
+	// The strip is an RGBA image as draw has fast paths converting the decoded images to it
+	strip := image.NewRGBA(image.Rect(0, 0, width, (height+dstHeight-1)/dstHeight+1))
+	white := &image.Uniform{C: color.White}
+
+	for dy := 0; dy < dstHeight; dy++ {
+		y0 := dy * height / dstHeight
+		y1 := max(y0+1, (dy+1)*height/dstHeight)
+
+		rows := image.Rect(0, 0, width, y1-y0)
+		draw.Draw(strip, rows, white, image.Point{}, draw.Src)
+		draw.Draw(strip, rows, src, image.Pt(bounds.Min.X, bounds.Min.Y+y0), draw.Over)
+
+		for dx := 0; dx < dstWidth; dx++ {
+			x0 := dx * width / dstWidth
+			x1 := max(x0+1, (dx+1)*width/dstWidth)
+
+			// The strip is opaque so its premultiplied colors are the plain ones
+			var sum [3]int
+			for y := 0; y < y1-y0; y++ {
+				row := strip.Pix[y*strip.Stride:]
+				for x := x0; x < x1; x++ {
+					for c := 0; c < 3; c++ {
+						sum[c] += int(row[x*4+c])
+					}
+				}
+			}
+
+			count := (y1 - y0) * (x1 - x0)
+			offset := dy*dst.Stride + dx*4
+			for c := 0; c < 3; c++ {
+				dst.Pix[offset+c] = uint8((sum[c] + count/2) / count)
+			}
+			dst.Pix[offset+3] = 0xff
+		}
+	}
+
+	return dst
+}
+
+// fitSize returns the size of an image scaled down so its longest side is at most maxSize
+func fitSize(width, height, maxSize int) (int, int) {
+	if width <= maxSize && height <= maxSize {
+		return width, height
+	}
+	if width >= height {
+		return maxSize, max(1, height*maxSize/width)
+	}
+	return max(1, width*maxSize/height), maxSize
+}
+
+// fit scales the image down so its longest side is at most maxSize, images are never scaled up
+func fit(src *image.NRGBA, maxSize int) *image.NRGBA {
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dstWidth, dstHeight := fitSize(width, height, maxSize)
+	if dstWidth == width && dstHeight == height {
+		return src
+	}
+
+	return downscale(src, dstWidth, dstHeight)
+}
+
+// downscale resizes the image with an area average, every destination pixel is the mean
+// of the source pixels it covers which avoids the aliasing of nearest neighbour sampling
+func downscale(src *image.NRGBA, dstWidth, dstHeight int) *image.NRGBA {
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for dy := 0; dy < dstHeight; dy++ {
+		y0 := dy * height / dstHeight
+		y1 := max(y0+1, (dy+1)*height/dstHeight)
+
+		for dx := 0; dx < dstWidth; dx++ {
+			x0 := dx * width / dstWidth
+			x1 := max(x0+1, (dx+1)*width/dstWidth)
+
+			var sum [4]int
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[x*4+c])
+					}
+				}
+			}
+
+			count := (y1 - y0) * (x1 - x0)
+			offset := dy*dst.Stride + dx*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8((sum[c] + count/2) / count)
+			}
+		}
+	}
+
+	return dst
+}
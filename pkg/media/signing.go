@@ -0,0 +1,50 @@
+package media
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// URLSigner builds expiring links to the stored files so they can be served
+// without authentication while staying private to the users they were given to
+type URLSigner struct {
+	BaseURL string
+	Key     []byte
+	TTL     time.Duration
+}
+
+func (s URLSigner) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.Key)
+	fmt.Fprintf(mac, "%s:%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignURL returns a link to the file valid for at least the TTL
+// The expiry is rounded to a multiple of the TTL so the links stay the same for a while
+// and the clients can cache the files
+func (s URLSigner) SignURL(key string, now time.Time) string {
+	ttl := int64(s.TTL / time.Second)
+	if ttl <= 0 {
+		ttl = 1
+	}
+	expires := (now.Unix()/ttl + 2) * ttl
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.signature(key, expires))
+	return fmt.Sprintf("%s/media/%s?%s", s.BaseURL, key, query.Encode())
+}
+
+// Verify reports whether the signature of the link is valid and the link hasn't expired
+func (s URLSigner) Verify(key string, expires string, signature string, now time.Time) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.signature(key, expiresAt)))
+}
@@ -0,0 +1,12 @@
+package models
+
+import (
+	"time"
+)
+
+type Block struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	BlockerID uint64    `json:"blockerID" gorm:"uniqueIndex:idx_block_pair;not null"`
+	BlockedID uint64    `json:"blockedID" gorm:"uniqueIndex:idx_block_pair;index;not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"not null"`
+}
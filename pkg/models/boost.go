@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// Boost raises the discover rank of the user for nearby viewers between StartsAt and EndsAt
+type Boost struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	UserID    uint64    `json:"userID" gorm:"not null;index"`
+	StartsAt  time.Time `json:"startsAt" gorm:"not null"`
+	EndsAt    time.Time `json:"endsAt" gorm:"not null;index"`
+	CreatedAt time.Time `json:"createdAt" gorm:"not null"`
+}
+
+// IsActive reports whether the boost is currently running
+func (b Boost) IsActive(now time.Time) bool {
+	return !b.StartsAt.After(now) && b.EndsAt.After(now)
+}
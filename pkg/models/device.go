@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// Push notification platforms
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+)
+
+type Device struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	UserID    uint64    `json:"userID" gorm:"index;not null"`
+	Platform  string    `json:"platform" gorm:"not null"`
+	Token     string    `json:"token" gorm:"size:255;uniqueIndex;not null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NotificationPreference holds the push notification settings of a user
+// Users without a row receive every notification at any time
+type NotificationPreference struct {
+	UserID            uint64    `json:"userID" gorm:"primaryKey;autoIncrement:false"`
+	Matches           bool      `json:"matches"`
+	Likes             bool      `json:"likes"`
+	Messages          bool      `json:"messages"`
+	QuietHoursEnabled bool      `json:"quietHoursEnabled"`
+	QuietHoursStart   string    `json:"quietHoursStart"`
+	QuietHoursEnd     string    `json:"quietHoursEnd"`
+	Timezone          string    `json:"timezone"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// DefaultNotificationPreference returns the settings used for users who never changed them
+func DefaultNotificationPreference(userID uint64) NotificationPreference {
+	return NotificationPreference{
+		UserID:          userID,
+		Matches:         true,
+		Likes:           true,
+		Messages:        true,
+		QuietHoursStart: "22:00",
+		QuietHoursEnd:   "08:00",
+		Timezone:        "UTC",
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// UserEvent is the persisted log of the realtime events sent to a user
+// It allows streaming clients to resume from the last event they received
+type UserEvent struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	UserID    uint64    `json:"userID" gorm:"index;not null"`
+	Type      string    `json:"type" gorm:"not null"`
+	Payload   string    `json:"payload" gorm:"type:text"`
+	CreatedAt time.Time `json:"createdAt" gorm:"not null;index"`
+}
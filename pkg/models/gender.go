@@ -0,0 +1,91 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Gender match groups, discover matches users on the group of their identity
+// so everyone interested in women sees trans women as well for instance
+const (
+	GenderGroupWoman     = "woman"
+	GenderGroupMan       = "man"
+	GenderGroupNonbinary = "nonbinary"
+)
+
+// GenderGroups lists the match groups users can be interested in
+var GenderGroups = []string{GenderGroupWoman, GenderGroupMan, GenderGroupNonbinary}
+
+// Slug of the identity letting users describe their gender in their own words
+const GenderIdentitySelfDescribed = "self-described"
+
+// GenderIdentity is an entry of the curated list of gender identities, admins can extend it
+type GenderIdentity struct {
+	ID           uint64    `json:"id" gorm:"primary_key"`
+	Slug         string    `json:"slug" gorm:"not null;uniqueIndex;size:64"`
+	Label        string    `json:"label" gorm:"not null"`
+	MatchGroup   string    `json:"matchGroup" gorm:"not null"`
+	AllowsCustom bool      `json:"allowsCustom" gorm:"not null;default:false"`
+	Position     int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// DefaultGenderIdentities is the initial list of gender identities
+var DefaultGenderIdentities = []GenderIdentity{
+	{Slug: "woman", Label: "Woman", MatchGroup: GenderGroupWoman, Position: 10},
+	{Slug: "man", Label: "Man", MatchGroup: GenderGroupMan, Position: 20},
+	{Slug: "non-binary", Label: "Non-binary", MatchGroup: GenderGroupNonbinary, Position: 30},
+	{Slug: "trans-woman", Label: "Trans woman", MatchGroup: GenderGroupWoman, Position: 40},
+	{Slug: "trans-man", Label: "Trans man", MatchGroup: GenderGroupMan, Position: 50},
+	{Slug: "genderqueer", Label: "Genderqueer", MatchGroup: GenderGroupNonbinary, Position: 60},
+	{Slug: "genderfluid", Label: "Genderfluid", MatchGroup: GenderGroupNonbinary, Position: 70},
+	{Slug: "agender", Label: "Agender", MatchGroup: GenderGroupNonbinary, Position: 80},
+	{Slug: GenderIdentitySelfDescribed, Label: "Self-described", MatchGroup: GenderGroupNonbinary, AllowsCustom: true, Position: 90},
+}
+
+// GenderLabel returns the gender shown on the profile of a user with the identity,
+// the custom text when the identity allows one
+func GenderLabel(identity GenderIdentity, custom string) string {
+	if identity.AllowsCustom && custom != "" {
+		return custom
+	}
+	return identity.Label
+}
+
+// Free-text genders used before the gender identities and the slug they map to
+var legacyGenderAliases = map[string]string{
+	"female":     "woman",
+	"females":    "woman",
+	"f":          "woman",
+	"women":      "woman",
+	"girl":       "woman",
+	"girls":      "woman",
+	"male":       "man",
+	"males":      "man",
+	"m":          "man",
+	"men":        "man",
+	"guy":        "man",
+	"guys":       "man",
+	"nonbinary":  "non-binary",
+	"nb":         "non-binary",
+	"enby":       "non-binary",
+	"enbies":     "non-binary",
+	"non binary": "non-binary",
+}
+
+// LegacyGenderSlug maps a free-text gender to the slug of an identity of the list
+// Values matching no identity are kept as the custom text of the self-described identity
+func LegacyGenderSlug(value string, identities []GenderIdentity) (slug string, custom string) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	if alias, ok := legacyGenderAliases[normalized]; ok {
+		normalized = alias
+	}
+
+	for _, identity := range identities {
+		if normalized == identity.Slug || normalized == strings.ToLower(identity.Label) {
+			return identity.Slug, ""
+		}
+	}
+	return GenderIdentitySelfDescribed, strings.TrimSpace(value)
+}
@@ -0,0 +1,29 @@
+package models
+
+import "testing"
+
+func TestLegacyGenderSlug(t *testing.T) {
+	tests := []struct {
+		value  string
+		slug   string
+		custom string
+	}{
+		{"Female", "woman", ""},
+		{" women ", "woman", ""},
+		{"males", "man", ""},
+		{"Guys", "man", ""},
+		{"enby", "non-binary", ""},
+		{"Trans woman", "trans-woman", ""},
+		{"trans-man", "trans-man", ""},
+		{" Two-spirit ", GenderIdentitySelfDescribed, "Two-spirit"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			slug, custom := LegacyGenderSlug(test.value, DefaultGenderIdentities)
+			if slug != test.slug || custom != test.custom {
+				t.Errorf("LegacyGenderSlug(%q) = %q, %q, want %q, %q", test.value, slug, custom, test.slug, test.custom)
+			}
+		})
+	}
+}
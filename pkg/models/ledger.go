@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// Ledger transaction kinds
+const (
+	LedgerTransactionPurchase = "purchase"
+	LedgerTransactionGrant    = "grant"
+	LedgerTransactionSpend    = "spend"
+)
+
+// LedgerAccount holds credits, the accounts of the users hold their balance while the
+// system accounts are the counterparts credits come from and go to
+// Balance is a cache of the sum of the entries of the account
+type LedgerAccount struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex;size:191"`
+	UserID    *uint64   `json:"userID" gorm:"index"`
+	Balance   int64     `json:"balance" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"createdAt" gorm:"not null"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"not null"`
+}
+
+// LedgerTransaction groups entries summing up to zero, transactions are append-only
+// and corrections are made with new transactions
+// IdempotencyKey identifies the operation that created the transaction, like a purchase ID,
+// so retrying the operation doesn't credit the user twice
+type LedgerTransaction struct {
+	ID             uint64        `json:"id" gorm:"primary_key"`
+	Kind           string        `json:"kind" gorm:"not null"`
+	IdempotencyKey *string       `json:"-" gorm:"uniqueIndex;size:191"`
+	Reference      string        `json:"reference"`
+	CreatedAt      time.Time     `json:"createdAt" gorm:"not null"`
+	Entries        []LedgerEntry `json:"entries" gorm:"foreignKey:TransactionID"`
+}
+
+// LedgerEntry moves Amount credits in or out (when negative) of an account
+type LedgerEntry struct {
+	ID            uint64    `json:"-" gorm:"primary_key"`
+	TransactionID uint64    `json:"-" gorm:"not null;index"`
+	AccountID     uint64    `json:"accountID" gorm:"not null;index"`
+	Amount        int64     `json:"amount" gorm:"not null"`
+	CreatedAt     time.Time `json:"-" gorm:"not null"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// DailyLikeCount is the number of likes a user sent on a given UTC day ("2006-01-02")
+// so the daily like limit is checked without counting the swipes
+type DailyLikeCount struct {
+	UserID uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Day    string `gorm:"primaryKey;size:10"`
+	Likes  int    `gorm:"not null;default:0"`
+}
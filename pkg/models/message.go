@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+type Message struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	MatchID   uint64    `json:"matchID" gorm:"index;not null"`
+	SenderID  uint64    `json:"senderID" gorm:"not null"`
+	Body      string    `json:"body" gorm:"type:text;not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"not null"`
+}
+
+// ConversationRead keeps track of the last message a user has read in a conversation
+type ConversationRead struct {
+	ID                uint64    `json:"id" gorm:"primary_key"`
+	MatchID           uint64    `json:"matchID" gorm:"uniqueIndex:idx_conversation_read_match_user;not null"`
+	UserID            uint64    `json:"userID" gorm:"uniqueIndex:idx_conversation_read_match_user;not null"`
+	LastReadMessageID uint64    `json:"lastReadMessageID" gorm:"not null"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
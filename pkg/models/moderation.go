@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+)
+
+// Report reasons users can pick from when reporting another user
+var ReportReasons = []string{
+	"spam",
+	"fake_profile",
+	"harassment",
+	"inappropriate_content",
+	"underage",
+	"scam",
+	"other",
+}
+
+// Moderation case statuses
+const (
+	CaseStatusOpen     = "open"
+	CaseStatusClaimed  = "claimed"
+	CaseStatusResolved = "resolved"
+)
+
+// Moderation actions, claim is recorded when a moderator takes a case
+// warn, suspend, ban and dismiss are the possible resolutions of a case
+// and the others are recorded when admins manage a user directly
+const (
+	ModerationActionClaim        = "claim"
+	ModerationActionWarn         = "warn"
+	ModerationActionSuspend      = "suspend"
+	ModerationActionBan          = "ban"
+	ModerationActionDismiss      = "dismiss"
+	ModerationActionReinstate    = "reinstate"
+	ModerationActionLogout       = "force_logout"
+	ModerationActionResetScore   = "reset_score"
+	ModerationActionImpersonate  = "impersonate"
+	ModerationActionShadowBan    = "shadow_ban"
+	ModerationActionUnshadowBan  = "unshadow_ban"
+	ModerationActionLikeLimit    = "like_limit"
+	ModerationActionGrantPlan    = "grant_plan"
+	ModerationActionRevokePlan   = "revoke_plan"
+	ModerationActionGrantCredits = "grant_credits"
+)
+
+type Report struct {
+	ID             uint64    `json:"id" gorm:"primary_key"`
+	ReporterID     uint64    `json:"reporterID" gorm:"index;not null"`
+	ReportedUserID uint64    `json:"reportedUserID" gorm:"index;not null"`
+	CaseID         uint64    `json:"caseID" gorm:"index;not null"`
+	Reason         string    `json:"reason" gorm:"not null"`
+	Details        string    `json:"details" gorm:"type:text"`
+	CreatedAt      time.Time `json:"createdAt" gorm:"not null"`
+}
+
+// ModerationCase groups the reports about a user until a moderator resolves them
+type ModerationCase struct {
+	ID            uint64     `json:"id" gorm:"primary_key"`
+	SubjectUserID uint64     `json:"subjectUserID" gorm:"index;not null"`
+	Status        string     `json:"status" gorm:"index;not null"`
+	AssigneeID    *uint64    `json:"assigneeID"`
+	Resolution    string     `json:"resolution"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"not null"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	ResolvedAt    *time.Time `json:"resolvedAt"`
+}
+
+// ModerationAction is the audit trail of everything moderators did
+type ModerationAction struct {
+	ID            uint64    `json:"id" gorm:"primary_key"`
+	CaseID        *uint64   `json:"caseID" gorm:"index"`
+	SubjectUserID uint64    `json:"subjectUserID" gorm:"index;not null"`
+	ModeratorID   uint64    `json:"moderatorID" gorm:"index;not null"`
+	Action        string    `json:"action" gorm:"not null"`
+	Note          string    `json:"note" gorm:"type:text"`
+	CreatedAt     time.Time `json:"createdAt" gorm:"not null"`
+}
+
+// Impersonation gives an admin a short lived token acting as another user
+// The reason is mandatory so every impersonation can be audited
+type Impersonation struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	AdminID   uint64    `json:"adminID" gorm:"index;not null"`
+	UserID    uint64    `json:"userID" gorm:"index;not null"`
+	Reason    string    `json:"reason" gorm:"type:text;not null"`
+	Token     string    `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"not null"`
+}
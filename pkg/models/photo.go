@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// Photo is a picture of the user's profile, its resized variants are kept in the blob store
+type Photo struct {
+	ID     uint64 `json:"id" gorm:"primary_key"`
+	UserID uint64 `json:"userID" gorm:"not null;index"`
+	// Position orders the photos of the user starting at 0
+	Position  int  `json:"position" gorm:"not null"`
+	IsPrimary bool `json:"isPrimary" gorm:"not null;default:false"`
+	Width     int  `json:"width" gorm:"not null"`
+	Height    int  `json:"height" gorm:"not null"`
+	// Perceptual hashes of the photo used to find the same photo on other accounts
+	PHash uint64 `json:"-" gorm:"not null;default:0"`
+	DHash uint64 `json:"-" gorm:"not null;default:0"`
+	// Variants maps the variant names to their blob store keys
+	Variants  map[string]string `json:"-" gorm:"serializer:json;type:json"`
+	CreatedAt time.Time         `json:"createdAt" gorm:"not null"`
+}
+
+// Duplicate photo flag statuses
+const (
+	DuplicatePhotoFlagStatusOpen     = "open"
+	DuplicatePhotoFlagStatusReviewed = "reviewed"
+)
+
+// PhotoHashBand indexes a band of the pHash of a photo, near duplicate photos
+// always share a band close enough to be found without comparing every photo
+type PhotoHashBand struct {
+	PhotoID uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Band    uint8  `gorm:"primaryKey;autoIncrement:false;index:idx_photo_hash_band_value,priority:1"`
+	Value   uint16 `gorm:"not null;index:idx_photo_hash_band_value,priority:2"`
+	UserID  uint64 `gorm:"not null"`
+}
+
+// DuplicatePhotoFlag is a photo looking like the photo of another account, waiting for an admin review
+// Stolen photos reused by fake accounts are the usual reason
+type DuplicatePhotoFlag struct {
+	ID      uint64 `json:"id" gorm:"primary_key"`
+	PhotoID uint64 `json:"photoID" gorm:"not null;uniqueIndex:idx_duplicate_photo_pair"`
+	UserID  uint64 `json:"userID" gorm:"not null;index"`
+	// MatchedPhotoID is the earlier photo of the other account
+	MatchedPhotoID uint64 `json:"matchedPhotoID" gorm:"not null;uniqueIndex:idx_duplicate_photo_pair"`
+	MatchedUserID  uint64 `json:"matchedUserID" gorm:"not null;index"`
+	// Hamming distances between the hashes of the photos, 0 for identical photos
+	PHashDistance int       `json:"pHashDistance" gorm:"not null"`
+	DHashDistance int       `json:"dHashDistance" gorm:"not null"`
+	Status        string    `json:"status" gorm:"index;not null"`
+	CreatedAt     time.Time `json:"createdAt" gorm:"not null"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Discovery preferences that can be marked as dealbreakers
+const (
+	PreferenceAge      = "age"
+	PreferenceGender   = "gender"
+	PreferenceDistance = "distance"
+)
+
+// Preferences lists the preferences that can be marked as dealbreakers
+var Preferences = []string{PreferenceAge, PreferenceGender, PreferenceDistance}
+
+// DiscoveryPreference holds who a user wants to see in discover, empty preferences match everyone
+// Profiles outside a dealbreaker preference are never shown, profiles outside the
+// other preferences are still shown after the ones matching every preference
+type DiscoveryPreference struct {
+	UserID        uint64    `json:"userID" gorm:"primaryKey;autoIncrement:false"`
+	MinAge        *int      `json:"minAge"`
+	MaxAge        *int      `json:"maxAge"`
+	Genders       []string  `json:"genders" gorm:"serializer:json;type:json"`
+	MaxDistanceKm *float64  `json:"maxDistanceKm"`
+	Dealbreakers  []string  `json:"dealbreakers" gorm:"serializer:json;type:json"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// DefaultDiscoveryPreference returns the preferences used for users who never set them
+func DefaultDiscoveryPreference(userID uint64) DiscoveryPreference {
+	return DiscoveryPreference{
+		UserID:       userID,
+		Genders:      []string{},
+		Dealbreakers: slices.Clone(Preferences),
+	}
+}
+
+// IsDealbreaker reports whether profiles outside the preference must be hidden
+func (p DiscoveryPreference) IsDealbreaker(preference string) bool {
+	return slices.Contains(p.Dealbreakers, preference)
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+)
+
+// Prompt is a question of the catalog users can answer on their profile, admins manage the catalog
+// Inactive prompts can't be answered anymore and their answers are hidden from the profiles
+type Prompt struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	Slug      string    `json:"slug" gorm:"not null;uniqueIndex;size:64"`
+	Question  string    `json:"question" gorm:"not null"`
+	Active    bool      `json:"active" gorm:"not null"`
+	Position  int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DefaultPrompts is the initial prompt catalog
+var DefaultPrompts = []Prompt{
+	{Slug: "two-truths-and-a-lie", Question: "Two truths and a lie", Active: true, Position: 10},
+	{Slug: "perfect-sunday", Question: "My perfect Sunday", Active: true, Position: 20},
+	{Slug: "green-flags", Question: "Green flags I look for", Active: true, Position: 30},
+	{Slug: "simple-pleasures", Question: "My simple pleasures", Active: true, Position: 40},
+	{Slug: "get-along-if", Question: "We'll get along if", Active: true, Position: 50},
+	{Slug: "wont-shut-up-about", Question: "I won't shut up about", Active: true, Position: 60},
+}
+
+// PromptAnswer is the answer of a user to a prompt, Position orders the answers on the profile
+type PromptAnswer struct {
+	UserID    uint64    `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	PromptID  uint64    `json:"promptID" gorm:"primaryKey;autoIncrement:false;index"`
+	Answer    string    `json:"answer" gorm:"type:text;not null"`
+	Position  int       `json:"position" gorm:"not null"`
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+// Interest is a tag of the interests taxonomy, grouped by category
+type Interest struct {
+	ID       uint64 `json:"id" gorm:"primary_key"`
+	Slug     string `json:"slug" gorm:"not null;uniqueIndex;size:64"`
+	Label    string `json:"label" gorm:"not null"`
+	Category string `json:"category" gorm:"not null;index;size:64"`
+	// Weight overrides the weight of the interest in the compatibility of two users,
+	// by default rarer interests weigh more, only the admin endpoints return it
+	Weight *float64 `json:"-"`
+}
+
+// DefaultInterests is the interests taxonomy
+var DefaultInterests = []Interest{
+	{Slug: "hiking", Label: "Hiking", Category: "sports"},
+	{Slug: "running", Label: "Running", Category: "sports"},
+	{Slug: "yoga", Label: "Yoga", Category: "sports"},
+	{Slug: "climbing", Label: "Climbing", Category: "sports"},
+	{Slug: "cycling", Label: "Cycling", Category: "sports"},
+	{Slug: "swimming", Label: "Swimming", Category: "sports"},
+	{Slug: "football", Label: "Football", Category: "sports"},
+	{Slug: "concerts", Label: "Concerts", Category: "music"},
+	{Slug: "jazz", Label: "Jazz", Category: "music"},
+	{Slug: "hip-hop", Label: "Hip hop", Category: "music"},
+	{Slug: "indie", Label: "Indie", Category: "music"},
+	{Slug: "classical-music", Label: "Classical music", Category: "music"},
+	{Slug: "cooking", Label: "Cooking", Category: "food"},
+	{Slug: "coffee", Label: "Coffee", Category: "food"},
+	{Slug: "wine", Label: "Wine", Category: "food"},
+	{Slug: "vegan", Label: "Vegan", Category: "food"},
+	{Slug: "brunch", Label: "Brunch", Category: "food"},
+	{Slug: "photography", Label: "Photography", Category: "arts"},
+	{Slug: "painting", Label: "Painting", Category: "arts"},
+	{Slug: "museums", Label: "Museums", Category: "arts"},
+	{Slug: "theatre", Label: "Theatre", Category: "arts"},
+	{Slug: "movies", Label: "Movies", Category: "entertainment"},
+	{Slug: "series", Label: "Series", Category: "entertainment"},
+	{Slug: "gaming", Label: "Gaming", Category: "entertainment"},
+	{Slug: "anime", Label: "Anime", Category: "entertainment"},
+	{Slug: "podcasts", Label: "Podcasts", Category: "entertainment"},
+	{Slug: "travel", Label: "Travel", Category: "lifestyle"},
+	{Slug: "dogs", Label: "Dogs", Category: "lifestyle"},
+	{Slug: "cats", Label: "Cats", Category: "lifestyle"},
+	{Slug: "gardening", Label: "Gardening", Category: "lifestyle"},
+	{Slug: "volunteering", Label: "Volunteering", Category: "lifestyle"},
+	{Slug: "reading", Label: "Reading", Category: "learning"},
+	{Slug: "languages", Label: "Languages", Category: "learning"},
+	{Slug: "science", Label: "Science", Category: "learning"},
+	{Slug: "history", Label: "History", Category: "learning"},
+}
+
+// UserInterest is an interest of the taxonomy chosen by a user
+type UserInterest struct {
+	UserID     uint64 `gorm:"primaryKey;autoIncrement:false"`
+	InterestID uint64 `gorm:"primaryKey;autoIncrement:false;index"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// Importance of a question for the user answering it, from the least to the most important
+const (
+	ImportanceIrrelevant = "irrelevant"
+	ImportanceALittle    = "a_little"
+	ImportanceSomewhat   = "somewhat"
+	ImportanceVery       = "very"
+	ImportanceMandatory  = "mandatory"
+)
+
+// Importances lists the importances users can give to their answers
+var Importances = []string{ImportanceIrrelevant, ImportanceALittle, ImportanceSomewhat, ImportanceVery, ImportanceMandatory}
+
+// Question is a multiple choice question of the compatibility questionnaire, admins manage the questionnaire
+// The options can't change once created since the answers refer to them by index
+type Question struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	Text      string    `json:"text" gorm:"not null"`
+	Options   []string  `json:"options" gorm:"serializer:json;type:json;not null"`
+	Active    bool      `json:"active" gorm:"not null"`
+	Position  int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// QuestionAnswer is the answer of a user to a question, along with the answers
+// they accept from others and how much it matters to them
+type QuestionAnswer struct {
+	UserID     uint64    `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	QuestionID uint64    `json:"questionID" gorm:"primaryKey;autoIncrement:false;index"`
+	Answer     int       `json:"answer" gorm:"not null"`
+	Acceptable []int     `json:"acceptable" gorm:"serializer:json;type:json;not null"`
+	Importance string    `json:"importance" gorm:"not null;size:20"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// QuestionnaireMatch caches the match percentage of two users, User1ID being the lowest ID
+// The versions are the questionnaire versions of the users when it was computed,
+// the match is stale once either user changed their answers
+type QuestionnaireMatch struct {
+	User1ID         uint64    `gorm:"primaryKey;autoIncrement:false"`
+	User2ID         uint64    `gorm:"primaryKey;autoIncrement:false"`
+	User1Version    int       `gorm:"not null"`
+	User2Version    int       `gorm:"not null"`
+	Percentage      int       `gorm:"not null"`
+	CommonQuestions int       `gorm:"not null"`
+	UpdatedAt       time.Time `gorm:"not null"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// RecommendationModel is a collaborative filtering model trained on the swipe history
+// by the train-recommendations job, discover uses the latest one
+type RecommendationModel struct {
+	ID           uint64    `json:"id" gorm:"primary_key"`
+	Factors      int       `json:"factors" gorm:"not null"`
+	GlobalBias   float64   `json:"globalBias" gorm:"not null"`
+	Interactions int64     `json:"interactions" gorm:"not null"`
+	Users        int64     `json:"users" gorm:"not null"`
+	Loss         float64   `json:"loss" gorm:"not null"`
+	TrainedAt    time.Time `json:"trainedAt" gorm:"not null"`
+}
+
+// UserEmbedding is the learned description of a user in a recommendation model,
+// Taste for the profiles they like and Appeal for the users who like them
+type UserEmbedding struct {
+	ModelID    uint64    `gorm:"primaryKey;autoIncrement:false"`
+	UserID     uint64    `gorm:"primaryKey;autoIncrement:false"`
+	Taste      []float64 `gorm:"serializer:json;type:json;not null"`
+	Appeal     []float64 `gorm:"serializer:json;type:json;not null"`
+	TasteBias  float64   `gorm:"not null"`
+	AppealBias float64   `gorm:"not null"`
+}
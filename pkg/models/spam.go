@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// Spam flag statuses
+const (
+	SpamFlagStatusOpen     = "open"
+	SpamFlagStatusReviewed = "reviewed"
+)
+
+// DiscoverFetch records every discover request, the spam detector compares
+// them with the swipes to find users swiping faster than humans
+type DiscoverFetch struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	UserID    uint64    `json:"userID" gorm:"index:idx_discover_fetch_user_time;not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index:idx_discover_fetch_user_time;index:idx_discover_fetch_created_at;not null"`
+}
+
+// SpamFlag is an account flagged by the spam detector along with the rule it triggered
+type SpamFlag struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	UserID    uint64    `json:"userID" gorm:"index;not null"`
+	Rule      string    `json:"rule" gorm:"not null"`
+	Detail    string    `json:"detail"`
+	Status    string    `json:"status" gorm:"index;not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"not null"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
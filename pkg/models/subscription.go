@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Subscription statuses, expired subscriptions keep the active status and are told apart by their end date
+const (
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusCancelled = "cancelled"
+)
+
+// Subscription sources
+const (
+	SubscriptionSourceAdmin     = "admin"
+	SubscriptionSourceAppStore  = "app_store"
+	SubscriptionSourcePlayStore = "play_store"
+)
+
+// Subscription grants a plan to the user from StartsAt until EndsAt, or forever when EndsAt is empty
+// Purchases are identified by the TransactionID of the store so a receipt can't be redeemed twice
+type Subscription struct {
+	ID            uint64     `json:"id" gorm:"primary_key"`
+	UserID        uint64     `json:"userID" gorm:"not null;index"`
+	Plan          string     `json:"plan" gorm:"not null"`
+	Status        string     `json:"status" gorm:"not null;default:active"`
+	Source        string     `json:"source" gorm:"not null"`
+	TransactionID *string    `json:"-" gorm:"uniqueIndex;size:191"`
+	StartsAt      time.Time  `json:"startsAt" gorm:"not null"`
+	EndsAt        *time.Time `json:"endsAt"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"not null"`
+	UpdatedAt     time.Time  `json:"updatedAt" gorm:"not null"`
+}
+
+// IsActive reports whether the subscription currently grants its plan
+func (s Subscription) IsActive(now time.Time) bool {
+	return s.Status == SubscriptionStatusActive && !s.StartsAt.After(now) && (s.EndsAt == nil || s.EndsAt.After(now))
+}
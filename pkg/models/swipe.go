@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Swipe struct {
@@ -13,8 +15,9 @@ type Swipe struct {
 }
 
 type Match struct {
-	ID        uint64    `json:"id" gorm:"primary_key"`
-	User1ID   uint64    `json:"user1ID" gorm:"foreignKey:User1ID;references:UserID"`
-	User2ID   uint64    `json:"user2ID" gorm:"foreignKey:User2ID;references:UserID"`
-	CreatedAt time.Time `json:"createdAt" gorm:"not null"`
+	ID        uint64         `json:"id" gorm:"primary_key"`
+	User1ID   uint64         `json:"user1ID" gorm:"foreignKey:User1ID;references:UserID"`
+	User2ID   uint64         `json:"user2ID" gorm:"foreignKey:User2ID;references:UserID"`
+	CreatedAt time.Time      `json:"createdAt" gorm:"not null"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
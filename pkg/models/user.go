@@ -4,19 +4,60 @@ import (
 	"time"
 )
 
+// Account statuses, suspended and banned users can't log in and are hidden from other users
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
+
+// Roles, moderators can work on the moderation queue and admins can use every admin endpoint
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
-	ID                    uint64  `gorm:"primaryKey;autoIncrement" json:"id" `
-	Email                 string  `gorm:"unique" json:"email"`
-	Password              string  `json:"password"`
-	Name                  string  `json:"name"`
-	Gender                string  `json:"gender"`
-	Age                   int     `json:"age"`
-	Latitude              float64 `json:"latitude"`
-	Longitude             float64 `json:"longitude"`
-	TotalLikesReceived    int     `json:"totalLikesReceived"`
-	TotalDislikesReceived int     `json:"totalDislikesReceived"`
-	AttractivenessScore   float64 `json:"attractivenessScore"`
-	Token                 Token   `gorm:"constraint:OnDelete:CASCADE;"`
+	ID       uint64 `gorm:"primaryKey;autoIncrement" json:"id" `
+	Email    string `gorm:"unique" json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	// Gender is the label of the gender identity of the user, or their own words
+	Gender                string     `json:"gender"`
+	GenderIdentityID      *uint64    `gorm:"index" json:"-"`
+	GenderGroup           string     `gorm:"size:20;index" json:"-"`
+	GenderCustom          string     `json:"-"`
+	ShowGender            bool       `gorm:"not null;default:true" json:"-"`
+	Age                   int        `json:"age"`
+	Bio                   string     `gorm:"type:text" json:"-"`
+	Latitude              float64    `json:"latitude"`
+	Longitude             float64    `json:"longitude"`
+	TotalLikesReceived    int        `json:"totalLikesReceived"`
+	TotalDislikesReceived int        `json:"totalDislikesReceived"`
+	AttractivenessScore   float64    `json:"attractivenessScore"`
+	Status                string     `gorm:"not null;default:active;index" json:"status"`
+	SuspendedUntil        *time.Time `json:"suspendedUntil"`
+	Role                  string     `gorm:"not null;default:user" json:"role"`
+	ShadowBanned          bool       `gorm:"not null;default:false;index" json:"-"`
+	// QuestionnaireVersion changes every time the user changes their questionnaire answers
+	QuestionnaireVersion int `gorm:"not null;default:0" json:"-"`
+	// DailyLikeLimit overrides the default daily like limit, zero means unlimited
+	DailyLikeLimit *int  `json:"-"`
+	Token          Token `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// IsRestricted reports whether the user is banned or currently suspended
+// Suspensions without an end date last until the user is reinstated
+func (u User) IsRestricted(now time.Time) bool {
+	switch u.Status {
+	case UserStatusBanned:
+		return true
+	case UserStatusSuspended:
+		return u.SuspendedUntil == nil || u.SuspendedUntil.After(now)
+	default:
+		return false
+	}
 }
 
 type Token struct {
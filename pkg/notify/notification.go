@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"dating-app/pkg/models"
+)
+
+// Notification categories, users can turn each of them off
+const (
+	CategoryMatch   = "match"
+	CategoryLike    = "like"
+	CategoryMessage = "message"
+)
+
+type Notification struct {
+	Category string            `json:"category"`
+	Title    string            `json:"title"`
+	Body     string            `json:"body"`
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+// BuildAPNsPayload builds the payload expected by the Apple Push Notification service
+// Custom data is sent next to the "aps" dictionary
+func BuildAPNsPayload(notification Notification) map[string]interface{} {
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{
+				"title": notification.Title,
+				"body":  notification.Body,
+			},
+			"sound":     "default",
+			"thread-id": notification.Category,
+		},
+	}
+	for key, value := range notification.Data {
+		payload[key] = value
+	}
+	return payload
+}
+
+// BuildFCMPayload builds the payload expected by the Firebase Cloud Messaging HTTP v1 API
+func BuildFCMPayload(token string, notification Notification) map[string]interface{} {
+	data := map[string]string{"category": notification.Category}
+	for key, value := range notification.Data {
+		data[key] = value
+	}
+
+	return map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": token,
+			"notification": map[string]string{
+				"title": notification.Title,
+				"body":  notification.Body,
+			},
+			"data": data,
+			"android": map[string]interface{}{
+				"notification": map[string]string{"tag": notification.Category},
+			},
+		},
+	}
+}
+
+// BuildPayload builds the payload matching the platform of the device
+func BuildPayload(device models.Device, notification Notification) map[string]interface{} {
+	if device.Platform == models.PlatformIOS {
+		return BuildAPNsPayload(notification)
+	}
+	return BuildFCMPayload(device.Token, notification)
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"dating-app/pkg/models"
+)
+
+// Notifier delivers a push notification to a single device
+// APNs and FCM backed implementations are expected to replace the development stubs in production
+type Notifier interface {
+	Send(device models.Device, notification Notification) error
+}
+
+// Delivery is the record written by the development notifiers for every notification
+type Delivery struct {
+	Platform string                 `json:"platform"`
+	Token    string                 `json:"token"`
+	Payload  map[string]interface{} `json:"payload"`
+	SentAt   time.Time              `json:"sentAt"`
+}
+
+func newDelivery(device models.Device, notification Notification) Delivery {
+	return Delivery{
+		Platform: device.Platform,
+		Token:    device.Token,
+		Payload:  BuildPayload(device, notification),
+		SentAt:   time.Now(),
+	}
+}
+
+// LogNotifier prints the notifications to the standard output
+type LogNotifier struct{}
+
+func (n LogNotifier) Send(device models.Device, notification Notification) error {
+	delivery, err := json.Marshal(newDelivery(device, notification))
+	if err != nil {
+		return err
+	}
+	fmt.Println("Push notification:", string(delivery))
+	return nil
+}
+
+// FileNotifier appends the notifications to a file, one JSON document per line
+type FileNotifier struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (n *FileNotifier) Send(device models.Device, notification Notification) error {
+	delivery, err := json.Marshal(newDelivery(device, notification))
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(delivery, '\n'))
+	return err
+}
+
+// HTTPNotifier posts the notifications to an HTTP endpoint, like a local stub server
+type HTTPNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n *HTTPNotifier) Send(device models.Device, notification Notification) error {
+	delivery, err := json.Marshal(newDelivery(device, notification))
+	if err != nil {
+		return err
+	}
+
+	response, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(delivery))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("push stub responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// NewNotifier creates the notifier matching the given kind (log, file or http)
+func NewNotifier(kind string, filePath string, url string) (Notifier, error) {
+	switch kind {
+	case "", "log":
+		return LogNotifier{}, nil
+	case "file":
+		return &FileNotifier{Path: filePath}, nil
+	case "http":
+		return &HTTPNotifier{URL: url, Client: &http.Client{Timeout: 5 * time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier: %s", kind)
+	}
+}
+
+var notifier Notifier = LogNotifier{}
+
+func SetNotifier(n Notifier) {
+	notifier = n
+}
+
+func GetNotifier() Notifier {
+	return notifier
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	// Embed the timezone database as the production image doesn't ship one
+	_ "time/tzdata"
+
+	"dating-app/pkg/models"
+)
+
+// ParseClock parses a "HH:MM" time of day and returns the number of minutes since midnight
+func ParseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day: %s", value)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// InQuietHours reports whether the given time falls in the quiet hours of the user
+// Quiet hours can wrap around midnight, like 22:00 to 08:00
+func InQuietHours(preference models.NotificationPreference, now time.Time) bool {
+	if !preference.QuietHoursEnabled {
+		return false
+	}
+
+	location, err := time.LoadLocation(preference.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	start, err := ParseClock(preference.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := ParseClock(preference.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	local := now.In(location)
+	minutes := local.Hour()*60 + local.Minute()
+
+	if start <= end {
+		return minutes >= start && minutes < end
+	}
+	return minutes >= start || minutes < end
+}
+
+// Allows reports whether the user wants to receive notifications of the given category
+func Allows(preference models.NotificationPreference, category string) bool {
+	switch category {
+	case CategoryMatch:
+		return preference.Matches
+	case CategoryLike:
+		return preference.Likes
+	case CategoryMessage:
+		return preference.Messages
+	default:
+		return true
+	}
+}
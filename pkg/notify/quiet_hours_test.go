@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"dating-app/pkg/models"
+)
+
+func TestInQuietHours(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		start    string
+		end      string
+		timezone string
+		now      time.Time
+		want     bool
+	}{
+		{"disabled", false, "00:00", "23:59", "UTC", time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC), false},
+		{"same day inside", true, "13:00", "15:00", "UTC", time.Date(2024, 5, 20, 14, 0, 0, 0, time.UTC), true},
+		{"same day before", true, "13:00", "15:00", "UTC", time.Date(2024, 5, 20, 12, 59, 0, 0, time.UTC), false},
+		{"start is inclusive", true, "13:00", "15:00", "UTC", time.Date(2024, 5, 20, 13, 0, 0, 0, time.UTC), true},
+		{"end is exclusive", true, "13:00", "15:00", "UTC", time.Date(2024, 5, 20, 15, 0, 0, 0, time.UTC), false},
+		{"overnight before midnight", true, "22:00", "08:00", "UTC", time.Date(2024, 5, 20, 23, 30, 0, 0, time.UTC), true},
+		{"overnight after midnight", true, "22:00", "08:00", "UTC", time.Date(2024, 5, 20, 7, 59, 0, 0, time.UTC), true},
+		{"overnight daytime", true, "22:00", "08:00", "UTC", time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC), false},
+		{"overnight end is exclusive", true, "22:00", "08:00", "UTC", time.Date(2024, 5, 20, 8, 0, 0, 0, time.UTC), false},
+		{"timezone of the user", true, "22:00", "08:00", "Europe/Berlin", time.Date(2024, 5, 20, 21, 30, 0, 0, time.UTC), true},
+		{"timezone outside", true, "22:00", "08:00", "America/New_York", time.Date(2024, 5, 20, 23, 30, 0, 0, time.UTC), false},
+		{"unknown timezone falls back to UTC", true, "22:00", "08:00", "Nowhere/Town", time.Date(2024, 5, 20, 23, 30, 0, 0, time.UTC), true},
+		{"invalid clock", true, "25:00", "08:00", "UTC", time.Date(2024, 5, 20, 23, 30, 0, 0, time.UTC), false},
+		{"empty range", true, "10:00", "10:00", "UTC", time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			preference := models.NotificationPreference{
+				QuietHoursEnabled: test.enabled,
+				QuietHoursStart:   test.start,
+				QuietHoursEnd:     test.end,
+				Timezone:          test.timezone,
+			}
+			if got := InQuietHours(preference, test.now); got != test.want {
+				t.Errorf("InQuietHours(%s-%s %s, %s) = %v, want %v", test.start, test.end, test.timezone, test.now.Format(time.RFC3339), got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"00:00", 0, false},
+		{"08:30", 510, false},
+		{"23:59", 1439, false},
+		{"24:00", 0, true},
+		{"8am", 0, true},
+		{"", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseClock(test.value)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("ParseClock(%q) = %d, %v, want %d, error %v", test.value, got, err, test.want, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Number of calls between two sweeps of the idle buckets
+const sweepEvery = 10000
+
+// MemoryStore keeps the buckets in the memory of the process
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(key string, policy Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		// New clients start with a full bucket
+		b = &bucket{tokens: float64(policy.Limit), updatedAt: now}
+		s.buckets[key] = b
+	}
+	b.window = policy.Window
+
+	return b.take(policy, now), nil
+}
+
+// sweep drops the buckets idle for longer than their window, they would be full by now
+// and recreating them gives the same result
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.updatedAt) > b.window {
+			delete(s.buckets, key)
+		}
+	}
+}
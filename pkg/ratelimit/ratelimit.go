@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy allows Limit requests per Window, as a token bucket holding up to Limit tokens
+// refilled continuously so short bursts are allowed as long as the average rate is respected
+type Policy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// ParsePolicy parses a policy written as "<limit>/<window>", like "60/1m"
+func ParsePolicy(value string) (Policy, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return Policy{}, fmt.Errorf("invalid rate limit policy: %s", value)
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit < 1 {
+		return Policy{}, fmt.Errorf("invalid rate limit: %s", parts[0])
+	}
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit window: %s", parts[1])
+	}
+
+	return Policy{Limit: limit, Window: window}, nil
+}
+
+// refillRate returns the number of tokens added to the bucket per second
+func (p Policy) refillRate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// Result describes the state of the bucket after taking a token
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is the number of requests left in the bucket
+	Remaining int
+	// ResetAfter is the time until the bucket is full again
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request is allowed, zero when allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets of every client
+// The in-memory store works for a single instance, a shared store (like Redis)
+// is needed once the API runs on several instances
+type Store interface {
+	Take(key string, policy Policy, now time.Time) (Result, error)
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	window    time.Duration
+}
+
+// take refills the bucket for the elapsed time and takes a token if there is one
+func (b *bucket) take(policy Policy, now time.Time) Result {
+	rate := policy.refillRate()
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(policy.Limit), b.tokens+elapsed*rate)
+		b.updatedAt = now
+	}
+
+	result := Result{Limit: policy.Limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+
+	result.Remaining = int(math.Floor(b.tokens))
+	result.ResetAfter = secondsToDuration((float64(policy.Limit) - b.tokens) / rate)
+
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+var store Store = NewMemoryStore()
+
+// SetStore replaces the default in-memory store
+func SetStore(s Store) {
+	store = s
+}
+
+func GetStore() Store {
+	return store
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Policy
+		wantErr bool
+	}{
+		{"60/1m", Policy{Limit: 60, Window: time.Minute}, false},
+		{"5/30s", Policy{Limit: 5, Window: 30 * time.Second}, false},
+		{"1000/24h", Policy{Limit: 1000, Window: 24 * time.Hour}, false},
+		{"60", Policy{}, true},
+		{"0/1m", Policy{}, true},
+		{"-1/1m", Policy{}, true},
+		{"ten/1m", Policy{}, true},
+		{"60/0s", Policy{}, true},
+		{"60/minute", Policy{}, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParsePolicy(test.value)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("ParsePolicy(%q) = %+v, %v, want %+v, error %v", test.value, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	// 4 requests per 4 seconds, a token is added every second
+	policy := Policy{Limit: 4, Window: 4 * time.Second}
+	start := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name          string
+		key           string
+		at            time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+		wantReset     time.Duration
+	}{
+		{"new clients start full", "a", 0, true, 3, 0, time.Second},
+		{"burst", "a", 0, true, 2, 0, 2 * time.Second},
+		{"burst", "a", 0, true, 1, 0, 3 * time.Second},
+		{"burst up to the limit", "a", 0, true, 0, 0, 4 * time.Second},
+		{"empty bucket", "a", 0, false, 0, time.Second, 4 * time.Second},
+		{"partial refill is not enough", "a", 500 * time.Millisecond, false, 0, 500 * time.Millisecond, 3500 * time.Millisecond},
+		{"other clients have their own bucket", "b", 500 * time.Millisecond, true, 3, 0, time.Second},
+		{"one token refilled after a second", "a", time.Second, true, 0, 0, 4 * time.Second},
+		{"refill is capped at the limit", "a", time.Minute, true, 3, 0, time.Second},
+	}
+
+	store := NewMemoryStore()
+	for _, step := range steps {
+		result, err := store.Take(step.key, policy, start.Add(step.at))
+		if err != nil {
+			t.Fatalf("%s: Take() error = %v", step.name, err)
+		}
+		if result.Allowed != step.wantAllowed || result.Remaining != step.wantRemaining ||
+			result.RetryAfter != step.wantRetry || result.ResetAfter != step.wantReset || result.Limit != policy.Limit {
+			t.Errorf("%s: Take(%s, +%s) = %+v, want allowed %v, remaining %d, retry after %s, reset after %s",
+				step.name, step.key, step.at, result, step.wantAllowed, step.wantRemaining, step.wantRetry, step.wantReset)
+		}
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	policy := Policy{Limit: 1, Window: time.Minute}
+	start := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore()
+	store.Take("idle", policy, start)
+	store.Take("active", policy, start.Add(time.Minute))
+
+	// Buckets idle for exactly their window are kept, they may not be full yet
+	store.sweep(start.Add(time.Minute))
+	if len(store.buckets) != 2 {
+		t.Fatalf("sweep() kept %d buckets, want 2", len(store.buckets))
+	}
+
+	store.sweep(start.Add(time.Minute + time.Nanosecond))
+	if _, exists := store.buckets["idle"]; exists {
+		t.Error("sweep() kept the idle bucket")
+	}
+	if _, exists := store.buckets["active"]; !exists {
+		t.Error("sweep() dropped the active bucket")
+	}
+
+	// A swept bucket is recreated full
+	result, _ := store.Take("idle", policy, start.Add(time.Minute+time.Nanosecond))
+	if !result.Allowed {
+		t.Error("Take() on a swept bucket was not allowed")
+	}
+}
@@ -0,0 +1,87 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+)
+
+// Broker fans out events to every subscription of a user
+// The in-process Hub is used by default, a pub/sub backed implementation
+// can replace it when the API runs on more than one instance
+type Broker interface {
+	Publish(userID uint64, event Event)
+	Subscribe(userID uint64, buffer int) *Subscription
+	Unsubscribe(subscription *Subscription)
+}
+
+// Subscription receives the events of a single user for a single connection
+// Publishing never blocks, when the buffer of a slow subscriber fills up
+// the subscription is closed and the client is expected to reconnect
+type Subscription struct {
+	UserID uint64
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+func NewSubscription(userID uint64, buffer int) *Subscription {
+	return &Subscription{
+		UserID: userID,
+		events: make(chan Event, buffer),
+		done:   make(chan struct{}),
+	}
+}
+
+// Events returns the channel the subscriber reads the events from
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the subscription was closed by the broker
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Deliver queues the event without blocking and closes the subscription
+// if the subscriber is not keeping up
+func (s *Subscription) Deliver(event Event) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	select {
+	case s.events <- event:
+		return true
+	default:
+		s.Close()
+		return false
+	}
+}
+
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+var broker Broker = NewHub()
+
+// SetBroker replaces the default in-process hub
+func SetBroker(b Broker) {
+	broker = b
+}
+
+func GetBroker() Broker {
+	return broker
+}
+
+// Publish sends an event to every connection of the user
+func Publish(userID uint64, eventType string, data interface{}) {
+	GetBroker().Publish(userID, Event{
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now(),
+	})
+}
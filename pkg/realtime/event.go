@@ -0,0 +1,24 @@
+package realtime
+
+import (
+	"time"
+)
+
+// Event types pushed to the connected clients
+const (
+	EventMatchCreated   = "match.created"
+	EventLikeReceived   = "like.received"
+	EventProfileUpdated = "profile.updated"
+	EventMessageCreated = "message.created"
+	EventMessageRead    = "message.read"
+	EventMessageTyping  = "message.typing"
+	EventAccountWarning = "account.warning"
+	EventResyncRequired = "resync.required"
+)
+
+type Event struct {
+	ID        uint64      `json:"id,omitempty"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
@@ -0,0 +1,54 @@
+package realtime
+
+import (
+	"sync"
+)
+
+// Hub is the in-process Broker, it keeps track of the open subscriptions of every user
+type Hub struct {
+	mu            sync.RWMutex
+	subscriptions map[uint64]map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscriptions: make(map[uint64]map[*Subscription]struct{})}
+}
+
+func (h *Hub) Publish(userID uint64, event Event) {
+	h.mu.RLock()
+	subscriptions := make([]*Subscription, 0, len(h.subscriptions[userID]))
+	for subscription := range h.subscriptions[userID] {
+		subscriptions = append(subscriptions, subscription)
+	}
+	h.mu.RUnlock()
+
+	for _, subscription := range subscriptions {
+		if !subscription.Deliver(event) {
+			h.Unsubscribe(subscription)
+		}
+	}
+}
+
+func (h *Hub) Subscribe(userID uint64, buffer int) *Subscription {
+	subscription := NewSubscription(userID, buffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscriptions[userID] == nil {
+		h.subscriptions[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscriptions[userID][subscription] = struct{}{}
+
+	return subscription
+}
+
+func (h *Hub) Unsubscribe(subscription *Subscription) {
+	subscription.Close()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscriptions[subscription.UserID], subscription)
+	if len(h.subscriptions[subscription.UserID]) == 0 {
+		delete(h.subscriptions, subscription.UserID)
+	}
+}
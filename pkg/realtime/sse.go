@@ -0,0 +1,121 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Interval between the keep alive comments sent on idle streams
+const sseHeartbeatPeriod = 30 * time.Second
+
+// ErrReplayUnavailable is returned by a ReplayFunc when the events after the given ID
+// can't be replayed anymore, for instance because they were deleted by the retention job
+var ErrReplayUnavailable = errors.New("events can't be replayed")
+
+// ReplayFunc returns a batch of the persisted events of the user sent after the given event ID,
+// it's called until it returns no event
+type ReplayFunc func(userID uint64, lastEventID uint64) ([]Event, error)
+
+// ServeEventStream streams the events of the user as Server-Sent Events
+// Events missed since lastEventID are replayed first when a replay function is given
+func ServeEventStream(w http.ResponseWriter, r *http.Request, userID uint64, lastEventID uint64, replay ReplayFunc) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("streaming is not supported by the response writer")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// Ask the clients to wait a few seconds before reconnecting
+	fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	// Replay the missed events batch by batch until caught up, when they can't be replayed
+	// the client is told to fetch its state again and the stream continues with the live events
+	replaying := lastEventID != 0 && replay != nil
+	catchUp := func() error {
+		for replaying {
+			events, err := replay(userID, lastEventID)
+			if errors.Is(err, ErrReplayUnavailable) {
+				replaying = false
+				if err := writeServerSentEvent(w, Event{Type: EventResyncRequired, CreatedAt: time.Now()}); err != nil {
+					return err
+				}
+				flusher.Flush()
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				return nil
+			}
+			for _, event := range events {
+				if err := writeServerSentEvent(w, event); err != nil {
+					return err
+				}
+				lastEventID = event.ID
+			}
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	// Subscribe once the bulk of the missed events is sent, the live events would otherwise fill
+	// the buffer of the subscription during a long replay and close it, then replay again the events
+	// sent in between so none is lost
+	if err := catchUp(); err != nil {
+		return err
+	}
+	subscription := GetBroker().Subscribe(userID, sendBufferSize)
+	defer GetBroker().Unsubscribe(subscription)
+	if err := catchUp(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(sseHeartbeatPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-subscription.Events():
+			// Skip the events already sent during the replay
+			if event.ID != 0 && event.ID <= lastEventID {
+				continue
+			}
+			if err := writeServerSentEvent(w, event); err != nil {
+				return err
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return err
+			}
+			flusher.Flush()
+		case <-subscription.Done():
+			return nil
+		case <-r.Context().Done():
+			return nil
+		}
+	}
+}
+
+func writeServerSentEvent(w http.ResponseWriter, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// Events without an ID were not persisted and can't be resumed from
+	if event.ID != 0 {
+		fmt.Fprintf(w, "id: %d\n", event.ID)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
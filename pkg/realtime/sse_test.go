@@ -0,0 +1,82 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A long replay while live events keep coming must not close the stream, and every event
+// must be sent exactly once and in order
+func TestServeEventStreamLongReplay(t *testing.T) {
+	SetBroker(NewHub())
+	defer SetBroker(NewHub())
+
+	const userID = 1
+	var mu sync.Mutex
+	var log []Event
+	emit := func() {
+		mu.Lock()
+		event := Event{ID: uint64(len(log) + 1), Type: "message.created", CreatedAt: time.Now()}
+		log = append(log, event)
+		mu.Unlock()
+		GetBroker().Publish(userID, event)
+	}
+	for i := 0; i < 2000; i++ {
+		emit()
+	}
+
+	// More live events than the subscription buffer holds are sent during the first batches
+	batches := 0
+	replay := func(userID uint64, lastEventID uint64) ([]Event, error) {
+		if batches++; batches <= 3 {
+			for i := 0; i < 2*sendBufferSize; i++ {
+				emit()
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		end := min(int(lastEventID)+500, len(log))
+		return append([]Event(nil), log[lastEventID:end]...), nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+	served := make(chan error)
+	go func() {
+		served <- ServeEventStream(recorder, request, userID, 1, replay)
+	}()
+
+	// Live events once the replay is over
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		emit()
+	}
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatalf("ServeEventStream() = %v", err)
+	}
+
+	var ids []string
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if id, ok := strings.CutPrefix(line, "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ids) != len(log)-1 {
+		t.Fatalf("stream sent %d events, want %d", len(ids), len(log)-1)
+	}
+	for i, id := range ids {
+		if want := fmt.Sprint(i + 2); id != want {
+			t.Fatalf("event %d has ID %s, want %s", i, id, want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ProtocolVersion is sent to the client in the hello frame
+// so clients can detect frames they don't understand yet
+const ProtocolVersion = 1
+
+// Frame types of the WebSocket protocol
+// Every frame is a JSON object with a type, the other fields depend on the type
+const (
+	FrameHello  = "hello"
+	FrameEvent  = "event"
+	FramePing   = "ping"
+	FramePong   = "pong"
+	FrameError  = "error"
+	FrameTyping = "typing"
+)
+
+const (
+	// Time allowed to write a frame to the client
+	writeWait = 10 * time.Second
+
+	// Time allowed to read the next pong from the client
+	pongWait = 60 * time.Second
+
+	// Send pings to the client with this period, must be less than pongWait
+	pingPeriod = (pongWait * 9) / 10
+
+	// Maximum size of a frame sent by the client
+	maxFrameSize = 4096
+
+	// Number of events buffered per connection before it's considered too slow
+	sendBufferSize = 64
+)
+
+type Frame struct {
+	Type  string          `json:"type"`
+	Event *Event          `json:"event,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// FrameHandler handles the frames sent by the client other than the ping frame
+// Returning an error sends an error frame back to the client
+type FrameHandler func(userID uint64, frame Frame) error
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Connections are authenticated with the user token and not with cookies
+	// so accepting connections from any origin is safe
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// ServeWebSocket upgrades the request and streams the events of the user over the connection
+// until the client disconnects, stops answering pings or can't keep up with the events
+func ServeWebSocket(w http.ResponseWriter, r *http.Request, userID uint64, handleFrame FrameHandler) error {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+
+	subscription := GetBroker().Subscribe(userID, sendBufferSize)
+	defer GetBroker().Unsubscribe(subscription)
+
+	// Frames generated by the read loop are handed over to the write loop
+	// as gorilla connections only support one concurrent writer
+	replies := make(chan Frame, 8)
+	go readLoop(conn, userID, handleFrame, replies, subscription)
+
+	return writeLoop(conn, userID, replies, subscription)
+}
+
+func readLoop(conn *websocket.Conn, userID uint64, handleFrame FrameHandler, replies chan<- Frame, subscription *Subscription) {
+	defer subscription.Close()
+
+	conn.SetReadLimit(maxFrameSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var frame Frame
+		if err := conn.ReadJSON(&frame); err != nil {
+			return
+		}
+
+		var reply *Frame
+		switch frame.Type {
+		case FramePing:
+			reply = &Frame{Type: FramePong}
+		default:
+			if handleFrame == nil {
+				reply = errorFrame(fmt.Sprintf("Unsupported frame type: %s", frame.Type))
+			} else if err := handleFrame(userID, frame); err != nil {
+				reply = errorFrame(err.Error())
+			}
+		}
+
+		if reply != nil {
+			select {
+			case replies <- *reply:
+			case <-subscription.Done():
+				return
+			}
+		}
+	}
+}
+
+func writeLoop(conn *websocket.Conn, userID uint64, replies <-chan Frame, subscription *Subscription) error {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	hello, _ := json.Marshal(map[string]interface{}{"protocol": ProtocolVersion, "userID": userID})
+	if err := writeFrame(conn, Frame{Type: FrameHello, Data: hello}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case event := <-subscription.Events():
+			if err := writeFrame(conn, Frame{Type: FrameEvent, Event: &event}); err != nil {
+				return err
+			}
+		case reply := <-replies:
+			if err := writeFrame(conn, reply); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return err
+			}
+		case <-subscription.Done():
+			// Either the client went away or it was too slow to keep up with its events
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "Connection closed"))
+			return nil
+		}
+	}
+}
+
+func writeFrame(conn *websocket.Conn, frame Frame) error {
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return conn.WriteJSON(frame)
+}
+
+func errorFrame(message string) *Frame {
+	data, _ := json.Marshal(map[string]string{"message": message})
+	return &Frame{Type: FrameError, Data: data}
+}
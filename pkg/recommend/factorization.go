@@ -0,0 +1,163 @@
+package recommend
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Interaction is a swipe of the swipe history, a like or a pass
+type Interaction struct {
+	SwiperID uint64
+	TargetID uint64
+	Liked    bool
+}
+
+// Config holds the hyperparameters of the training
+type Config struct {
+	// Factors is the size of the embeddings
+	Factors        int
+	Epochs         int
+	LearningRate   float64
+	Regularization float64
+	Seed           int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Factors:        16,
+		Epochs:         30,
+		LearningRate:   0.05,
+		Regularization: 0.01,
+		Seed:           1,
+	}
+}
+
+// Embedding describes a user on both sides of a swipe: Taste and TasteBias describe what
+// the user likes and how much they like in general when swiping, Appeal and AppealBias
+// describe who likes the user and how much they are liked in general when swiped on
+type Embedding struct {
+	Taste      []float64
+	Appeal     []float64
+	TasteBias  float64
+	AppealBias float64
+}
+
+// Model is a logistic matrix factorization of the swipe history, the probability that
+// a user likes another is the sigmoid of the dot product of the taste of the swiper
+// and the appeal of the target plus their biases
+type Model struct {
+	Factors    int
+	GlobalBias float64
+	Embeddings map[uint64]*Embedding
+	// Loss is the mean log loss of the last epoch
+	Loss float64
+}
+
+// Train fits the model to the interactions with stochastic gradient descent
+func Train(interactions []Interaction, config Config) *Model {
+	random := rand.New(rand.NewSource(config.Seed))
+
+	model := &Model{
+		Factors:    config.Factors,
+		Embeddings: map[uint64]*Embedding{},
+	}
+	if len(interactions) == 0 {
+		return model
+	}
+
+	// Start from the overall like rate so the embeddings only have to learn the differences
+	likes := 0
+	for _, interaction := range interactions {
+		if interaction.Liked {
+			likes++
+		}
+	}
+	likeRate := math.Min(math.Max(float64(likes)/float64(len(interactions)), 0.01), 0.99)
+	model.GlobalBias = math.Log(likeRate / (1 - likeRate))
+
+	embedding := func(userID uint64) *Embedding {
+		if existing, ok := model.Embeddings[userID]; ok {
+			return existing
+		}
+		created := &Embedding{Taste: make([]float64, config.Factors), Appeal: make([]float64, config.Factors)}
+		for f := 0; f < config.Factors; f++ {
+			created.Taste[f] = random.NormFloat64() * 0.1
+			created.Appeal[f] = random.NormFloat64() * 0.1
+		}
+		model.Embeddings[userID] = created
+		return created
+	}
+	for _, interaction := range interactions {
+		embedding(interaction.SwiperID)
+		embedding(interaction.TargetID)
+	}
+
+	learningRate := config.LearningRate
+	regularization := config.Regularization
+	for epoch := 0; epoch < config.Epochs; epoch++ {
+		var loss float64
+		for _, i := range random.Perm(len(interactions)) {
+			interaction := interactions[i]
+			swiper := model.Embeddings[interaction.SwiperID]
+			target := model.Embeddings[interaction.TargetID]
+
+			label := 0.0
+			if interaction.Liked {
+				label = 1
+			}
+			probability := model.predict(swiper, target)
+			loss -= label*math.Log(probability+1e-12) + (1-label)*math.Log(1-probability+1e-12)
+
+			// Gradient of the log loss with respect to the score
+			gradient := label - probability
+
+			model.GlobalBias += learningRate * gradient
+			swiper.TasteBias += learningRate * (gradient - regularization*swiper.TasteBias)
+			target.AppealBias += learningRate * (gradient - regularization*target.AppealBias)
+			for f := 0; f < config.Factors; f++ {
+				taste, appeal := swiper.Taste[f], target.Appeal[f]
+				swiper.Taste[f] += learningRate * (gradient*appeal - regularization*taste)
+				target.Appeal[f] += learningRate * (gradient*taste - regularization*appeal)
+			}
+		}
+		model.Loss = loss / float64(len(interactions))
+	}
+
+	return model
+}
+
+// Predict returns the probability that the swiper likes the target
+// Users missing from the model get the average behaviour
+func (m *Model) Predict(swiperID, targetID uint64) float64 {
+	return m.predict(m.Embeddings[swiperID], m.Embeddings[targetID])
+}
+
+func (m *Model) predict(swiper, target *Embedding) float64 {
+	return LikeProbability(m.GlobalBias, swiper, target)
+}
+
+// LikeProbability returns the probability that the swiper likes the target, nil embeddings
+// are users unknown to the model who only get the global bias
+func LikeProbability(globalBias float64, swiper, target *Embedding) float64 {
+	score := globalBias
+	if swiper != nil {
+		score += swiper.TasteBias
+	}
+	if target != nil {
+		score += target.AppealBias
+	}
+	if swiper != nil && target != nil {
+		for f := range swiper.Taste {
+			if f < len(target.Appeal) {
+				score += swiper.Taste[f] * target.Appeal[f]
+			}
+		}
+	}
+	return 1 / (1 + math.Exp(-score))
+}
+
+// Reciprocal combines the probability that the viewer likes the candidate and the probability that
+// the candidate likes the viewer back with a geometric mean, a match needs both
+func Reciprocal(viewerLikes, candidateLikes float64) float64 {
+	return math.Sqrt(viewerLikes * candidateLikes)
+}
@@ -0,0 +1,123 @@
+package recommend
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// twoClusters returns the swipes of users split into two clusters by the parity of their ID,
+// users like everyone of their own cluster and pass on everyone else
+// One pair out of five is held out of the training set to check the model generalizes
+func twoClusters(users int) (training, heldOut []Interaction) {
+	random := rand.New(rand.NewSource(7))
+	for swiper := 1; swiper <= users; swiper++ {
+		for target := 1; target <= users; target++ {
+			if swiper == target {
+				continue
+			}
+			interaction := Interaction{SwiperID: uint64(swiper), TargetID: uint64(target), Liked: swiper%2 == target%2}
+			if random.Intn(5) == 0 {
+				heldOut = append(heldOut, interaction)
+			} else {
+				training = append(training, interaction)
+			}
+		}
+	}
+	return training, heldOut
+}
+
+func TestTrainConvergesOnTwoClusters(t *testing.T) {
+	training, heldOut := twoClusters(40)
+
+	config := DefaultConfig()
+	config.Epochs = 1
+	firstEpoch := Train(training, config)
+	model := Train(training, DefaultConfig())
+
+	if model.Loss >= firstEpoch.Loss/2 {
+		t.Errorf("loss after %d epochs = %.3f, want under half the loss of the first epoch %.3f", DefaultConfig().Epochs, model.Loss, firstEpoch.Loss)
+	}
+
+	// Every held out swipe is predicted on the right side, and the clusters are well apart on average
+	var sameCluster, otherCluster []float64
+	for _, interaction := range heldOut {
+		probability := model.Predict(interaction.SwiperID, interaction.TargetID)
+		if interaction.Liked {
+			sameCluster = append(sameCluster, probability)
+			if probability < 0.5 {
+				t.Errorf("Predict(%d, %d) = %.3f, want a like", interaction.SwiperID, interaction.TargetID, probability)
+			}
+		} else {
+			otherCluster = append(otherCluster, probability)
+			if probability > 0.5 {
+				t.Errorf("Predict(%d, %d) = %.3f, want a pass", interaction.SwiperID, interaction.TargetID, probability)
+			}
+		}
+	}
+	if mean := average(sameCluster); mean < 0.8 {
+		t.Errorf("mean probability within a cluster = %.3f, want at least 0.8", mean)
+	}
+	if mean := average(otherCluster); mean > 0.2 {
+		t.Errorf("mean probability across clusters = %.3f, want at most 0.2", mean)
+	}
+}
+
+func TestTrainIsDeterministic(t *testing.T) {
+	training, _ := twoClusters(10)
+	a, b := Train(training, DefaultConfig()), Train(training, DefaultConfig())
+	if a.Loss != b.Loss || a.Predict(1, 3) != b.Predict(1, 3) {
+		t.Errorf("two trainings with the same seed differ")
+	}
+}
+
+func TestPredictUnknownUsers(t *testing.T) {
+	// Three likes out of four, the unknown users get the overall like rate
+	model := Train([]Interaction{
+		{SwiperID: 1, TargetID: 2, Liked: true},
+		{SwiperID: 2, TargetID: 1, Liked: true},
+		{SwiperID: 3, TargetID: 1, Liked: true},
+		{SwiperID: 1, TargetID: 3, Liked: false},
+	}, Config{Factors: 4, Epochs: 0, Seed: 1})
+
+	if got := model.Predict(100, 200); math.Abs(got-0.75) > 1e-9 {
+		t.Errorf("Predict of unknown users = %.3f, want 0.75", got)
+	}
+
+	empty := Train(nil, DefaultConfig())
+	if got := empty.Predict(1, 2); got != 0.5 {
+		t.Errorf("Predict of an empty model = %.3f, want 0.5", got)
+	}
+	if len(empty.Embeddings) != 0 {
+		t.Errorf("empty model has %d embeddings, want 0", len(empty.Embeddings))
+	}
+}
+
+func TestReciprocal(t *testing.T) {
+	tests := []struct {
+		viewerLikes, candidateLikes float64
+		want                        float64
+	}{
+		{1, 1, 1},
+		{0.25, 1, 0.5},
+		{0.9, 0, 0},
+		{0.4, 0.9, 0.6},
+	}
+
+	for _, test := range tests {
+		if got := Reciprocal(test.viewerLikes, test.candidateLikes); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("Reciprocal(%v, %v) = %v, want %v", test.viewerLikes, test.candidateLikes, got, test.want)
+		}
+		if got := Reciprocal(test.candidateLikes, test.viewerLikes); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("Reciprocal(%v, %v) = %v, want %v", test.candidateLikes, test.viewerLikes, got, test.want)
+		}
+	}
+}
+
+func average(values []float64) float64 {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
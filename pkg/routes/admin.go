@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+	"dating-app/pkg/models"
+)
+
+var (
+	requireModerator = core.RequireRole(models.RoleModerator, models.RoleAdmin)
+	requireAdmin     = core.RequireRole(models.RoleAdmin)
+)
+
+func RegisterAdminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/users", core.AuthMiddleware(requireAdmin(handlers.SearchUsers)))
+	mux.HandleFunc("/admin/users/{id}", core.AuthMiddleware(requireAdmin(handlers.GetAdminUser)))
+	mux.HandleFunc("/admin/users/{id}/role", core.AuthMiddleware(requireAdmin(handlers.UpdateUserRole)))
+	mux.HandleFunc("/admin/users/{id}/logout", core.AuthMiddleware(requireAdmin(handlers.ForceLogoutUser)))
+	mux.HandleFunc("/admin/users/{id}/reset-score", core.AuthMiddleware(requireAdmin(handlers.ResetUserScore)))
+	mux.HandleFunc("/admin/users/{id}/suspend", core.AuthMiddleware(requireAdmin(handlers.SuspendUser)))
+	mux.HandleFunc("/admin/users/{id}/reinstate", core.AuthMiddleware(requireAdmin(handlers.ReinstateUser)))
+	mux.HandleFunc("/admin/users/{id}/shadow-ban", core.AuthMiddleware(requireAdmin(handlers.ShadowBanUser)))
+	mux.HandleFunc("/admin/users/{id}/unshadow-ban", core.AuthMiddleware(requireAdmin(handlers.UnshadowBanUser)))
+	mux.HandleFunc("/admin/users/{id}/like-limit", core.AuthMiddleware(requireAdmin(handlers.SetUserLikeLimit)))
+	mux.HandleFunc("/admin/users/{id}/grant-plan", core.AuthMiddleware(requireAdmin(handlers.GrantUserPlan)))
+	mux.HandleFunc("/admin/users/{id}/revoke-plan", core.AuthMiddleware(requireAdmin(handlers.RevokeUserPlan)))
+	mux.HandleFunc("/admin/users/{id}/credits", core.AuthMiddleware(requireAdmin(handlers.GrantUserCredits)))
+	mux.HandleFunc("/admin/users/{id}/impersonate", core.AuthMiddleware(requireAdmin(handlers.ImpersonateUser)))
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterBlockRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/blocks", core.AuthMiddleware(handlers.Blocks))
+	mux.HandleFunc("/blocks/{userId}", core.AuthMiddleware(handlers.DeleteBlock))
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterBoostRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/boosts", core.AuthMiddleware(handlers.Boosts))
+	mux.HandleFunc("/boosts/{id}/stats", core.AuthMiddleware(handlers.GetBoostStats))
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterCreditsRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/me/credits", core.AuthMiddleware(handlers.GetCredits))
+	mux.HandleFunc("/me/credits/purchase", core.AuthMiddleware(handlers.PurchaseCredits))
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterDeviceRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/me/devices", core.AuthMiddleware(handlers.RegisterDevice))
+	mux.HandleFunc("/me/devices/{id}", core.AuthMiddleware(handlers.DeleteDevice))
+	mux.HandleFunc("/me/notification-preferences", core.AuthMiddleware(handlers.NotificationPreferences))
+}
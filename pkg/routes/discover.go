@@ -9,5 +9,5 @@ import (
 
 func RegisterDiscoverRoutes(mux *http.ServeMux) {
 
-	mux.HandleFunc("/discover", core.AuthMiddleware(handlers.GetPotentialMatches))
+	mux.HandleFunc("/discover", core.AuthMiddleware(core.RateLimit("discover")(handlers.GetPotentialMatches)))
 }
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterGenderRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/gender-identities", handlers.ListGenderIdentities)
+	mux.HandleFunc("/me/gender", core.AuthMiddleware(handlers.UserGender))
+	mux.HandleFunc("/admin/gender-identities", core.AuthMiddleware(requireAdmin(handlers.CreateGenderIdentity)))
+	mux.HandleFunc("/admin/gender-identities/{id}", core.AuthMiddleware(requireAdmin(handlers.UpdateGenderIdentity)))
+}
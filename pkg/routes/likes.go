@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterLikesRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/likes/received", core.AuthMiddleware(handlers.GetReceivedLikes))
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterMessageRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/matches/{id}/messages", core.AuthMiddleware(handlers.MatchMessages))
+	mux.HandleFunc("/matches/{id}/read", core.AuthMiddleware(handlers.MarkConversationRead))
+	mux.HandleFunc("/messages/unread", core.AuthMiddleware(handlers.GetUnreadMessageCounts))
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterModerationRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/reports", core.AuthMiddleware(handlers.CreateReport))
+
+	mux.HandleFunc("/admin/cases", core.AuthMiddleware(requireModerator(handlers.ListModerationCases)))
+	mux.HandleFunc("/admin/cases/{id}", core.AuthMiddleware(requireModerator(handlers.GetModerationCase)))
+	mux.HandleFunc("/admin/cases/{id}/claim", core.AuthMiddleware(requireModerator(handlers.ClaimModerationCase)))
+	mux.HandleFunc("/admin/cases/{id}/resolve", core.AuthMiddleware(requireModerator(handlers.ResolveModerationCase)))
+
+	mux.HandleFunc("/admin/spam-flags", core.AuthMiddleware(requireModerator(handlers.ListSpamFlags)))
+	mux.HandleFunc("/admin/spam-flags/{id}/review", core.AuthMiddleware(requireModerator(handlers.ReviewSpamFlag)))
+
+	mux.HandleFunc("/admin/duplicate-photos", core.AuthMiddleware(requireModerator(handlers.ListDuplicatePhotoFlags)))
+	mux.HandleFunc("/admin/duplicate-photos/{id}/review", core.AuthMiddleware(requireModerator(handlers.ReviewDuplicatePhotoFlag)))
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterPhotoRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/me/photos", core.AuthMiddleware(handlers.Photos))
+	mux.HandleFunc("/me/photos/order", core.AuthMiddleware(handlers.ReorderPhotos))
+	mux.HandleFunc("/me/photos/{id}", core.AuthMiddleware(handlers.DeletePhoto))
+	mux.HandleFunc("/me/photos/{id}/primary", core.AuthMiddleware(handlers.SetPrimaryPhoto))
+	mux.HandleFunc("/media/{key...}", handlers.ServeMedia)
+}
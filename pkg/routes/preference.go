@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterPreferenceRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/me/preferences", core.AuthMiddleware(handlers.DiscoveryPreferences))
+}
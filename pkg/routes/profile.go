@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterProfileRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/prompts", handlers.ListPrompts)
+	mux.HandleFunc("/interests", handlers.ListInterests)
+	mux.HandleFunc("/me/profile", core.AuthMiddleware(handlers.Profile))
+	mux.HandleFunc("/admin/prompts", core.AuthMiddleware(requireAdmin(handlers.CreatePrompt)))
+	mux.HandleFunc("/admin/prompts/{id}", core.AuthMiddleware(requireAdmin(handlers.UpdatePrompt)))
+	mux.HandleFunc("/admin/interests/{id}", core.AuthMiddleware(requireAdmin(handlers.UpdateInterest)))
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterQuestionRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/questions", handlers.ListQuestions)
+	mux.HandleFunc("/me/answers", core.AuthMiddleware(handlers.ListAnswers))
+	mux.HandleFunc("/me/answers/{id}", core.AuthMiddleware(handlers.Answer))
+	mux.HandleFunc("/admin/questions", core.AuthMiddleware(requireAdmin(handlers.CreateQuestion)))
+	mux.HandleFunc("/admin/questions/{id}", core.AuthMiddleware(requireAdmin(handlers.UpdateQuestion)))
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterRealtimeRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/ws", core.StreamAuthMiddleware(handlers.RealtimeSocket))
+	mux.HandleFunc("/events", core.StreamAuthMiddleware(handlers.EventStream))
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"net/http"
+
+	"dating-app/pkg/core"
+	"dating-app/pkg/handlers"
+)
+
+func RegisterSubscriptionRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/me/subscription", core.AuthMiddleware(handlers.GetSubscription))
+	mux.HandleFunc("/me/subscription/purchase", core.AuthMiddleware(handlers.PurchaseSubscription))
+}
@@ -8,5 +8,5 @@ import (
 )
 
 func RegisterSwipeRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/swipe", core.AuthMiddleware(handlers.UserSwipe))
+	mux.HandleFunc("/swipe", core.AuthMiddleware(core.RateLimit("swipe")(handlers.UserSwipe)))
 }
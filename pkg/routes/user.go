@@ -1,11 +1,12 @@
 package routes
 
 import (
+	"dating-app/pkg/core"
 	"dating-app/pkg/handlers"
 	"net/http"
 )
 
 func RegisterUserRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/login", handlers.UserLogin)
-	mux.HandleFunc("/user/create", handlers.CreateUser)
+	mux.HandleFunc("/login", core.RateLimit("login")(handlers.UserLogin))
+	mux.HandleFunc("/user/create", core.RateLimit("signup")(handlers.CreateUser))
 }
@@ -8,6 +8,8 @@ import (
 type AppError struct {
 	StatusCode int    `json:"statusCode"`
 	Message    string `json:"message"`
+	// Details carries structured information about the error for the clients
+	Details interface{} `json:"details,omitempty"`
 }
 
 func (e *AppError) Error() string {
@@ -18,6 +20,10 @@ func NewAppError(statusCode int, message string) *AppError {
 	return &AppError{StatusCode: statusCode, Message: message}
 }
 
+func NewAppErrorWithDetails(statusCode int, message string, details interface{}) *AppError {
+	return &AppError{StatusCode: statusCode, Message: message, Details: details}
+}
+
 func WriteErrorResponse(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	if appErr, ok := err.(*AppError); ok {
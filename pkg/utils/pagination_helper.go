@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ParsePagination reads the "page" and "pageSize" query parameters
+// falling back to the first page and the given default size when they are missing
+func ParsePagination(r *http.Request, defaultPageSize int, maxPageSize int) (int, int, error) {
+
+	page := 1
+	pageSize := defaultPageSize
+
+	if value := r.URL.Query().Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, NewAppError(http.StatusBadRequest, "Invalid page parameter")
+		}
+		page = parsed
+	}
+
+	if value := r.URL.Query().Get("pageSize"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, NewAppError(http.StatusBadRequest, "Invalid pageSize parameter")
+		}
+		pageSize = parsed
+	}
+
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return page, pageSize, nil
+}